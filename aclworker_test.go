@@ -0,0 +1,75 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingTokenProvider records how many times Token was called, so
+// tests can assert the worker triggers a refresh on ACL errors.
+type countingTokenProvider struct {
+	mutex sync.Mutex
+	calls int
+	token string
+}
+
+func (t *countingTokenProvider) Token(context.Context) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.calls++
+
+	return t.token, nil
+}
+
+func (t *countingTokenProvider) callCount() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.calls
+}
+
+func TestUpdateWorkerRefreshesTokenOnACLError(t *testing.T) {
+	kv := &fakeKV{
+		listErr: errors.New("Unexpected response code: 403 (Permission denied)"),
+	}
+	tp := &countingTokenProvider{token: "new-token"}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.updater = &countingUpdater{}
+	p.token = "old-token"
+	p.tokenProvider = tp
+	p.backoffBase = time.Millisecond
+	p.backoffMax = 2 * time.Millisecond
+	p.consulURLFn = func() (string, error) { return "http://127.0.0.1:8500", nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	deadline := time.Now().Add(time.Second)
+	for tp.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	stopped.Wait()
+
+	if tp.callCount() == 0 {
+		t.Fatal("TokenProvider.Token was never called after an ACL error")
+	}
+
+	p.protected.mutex.Lock()
+	lastErr := p.protected.lastErr
+	p.protected.mutex.Unlock()
+
+	if lastErr == nil {
+		t.Fatal("lastErr = nil, want the ACL error recorded")
+	}
+}
@@ -0,0 +1,33 @@
+package consul
+
+// adjustAdaptiveWaitTime shrinks the next blocking query's WaitTime
+// toward adaptiveMinWait right after a change was observed, and grows
+// it back toward adaptiveMaxWait during quiet polls, when
+// WithAdaptiveWaitTime is configured. It has no effect otherwise.
+func (r *provider) adjustAdaptiveWaitTime(changed bool) {
+	if r.adaptiveMinWait <= 0 {
+		return
+	}
+
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	current := r.protected.adaptiveWaitTime
+	if current <= 0 {
+		current = r.adaptiveMaxWait
+	}
+
+	if changed {
+		current /= 2
+		if current < r.adaptiveMinWait {
+			current = r.adaptiveMinWait
+		}
+	} else {
+		current *= 2
+		if current > r.adaptiveMaxWait {
+			current = r.adaptiveMaxWait
+		}
+	}
+
+	r.protected.adaptiveWaitTime = current
+}
@@ -0,0 +1,77 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestAdaptiveWaitTimeShrinksOnChangeAndGrowsWhenQuiet(t *testing.T) {
+	p := &provider{adaptiveMinWait: 100 * time.Millisecond, adaptiveMaxWait: 800 * time.Millisecond}
+
+	if got := p.effectiveWaitTime(); got != p.adaptiveMaxWait {
+		t.Fatalf("initial effectiveWaitTime = %s, want max %s", got, p.adaptiveMaxWait)
+	}
+
+	p.adjustAdaptiveWaitTime(true)
+	if got := p.effectiveWaitTime(); got != 400*time.Millisecond {
+		t.Fatalf("after one observed change, effectiveWaitTime = %s, want 400ms", got)
+	}
+
+	p.adjustAdaptiveWaitTime(true)
+	if got := p.effectiveWaitTime(); got != 200*time.Millisecond {
+		t.Fatalf("after two observed changes, effectiveWaitTime = %s, want 200ms", got)
+	}
+
+	p.adjustAdaptiveWaitTime(true)
+	p.adjustAdaptiveWaitTime(true)
+	if got := p.effectiveWaitTime(); got != p.adaptiveMinWait {
+		t.Fatalf("effectiveWaitTime = %s, want it floored at min %s", got, p.adaptiveMinWait)
+	}
+
+	p.adjustAdaptiveWaitTime(false)
+	if got := p.effectiveWaitTime(); got != 200*time.Millisecond {
+		t.Fatalf("after one quiet poll, effectiveWaitTime = %s, want 200ms", got)
+	}
+}
+
+func TestAdaptiveWaitTimeDisabledByDefault(t *testing.T) {
+	p := &provider{waitTime: 5 * time.Second}
+
+	if got := p.effectiveWaitTime(); got != 5*time.Second {
+		t.Fatalf("effectiveWaitTime = %s, want the static WithWaitTime value", got)
+	}
+}
+
+func TestListAdaptsWaitTimeAcrossPolls(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		lastIndex: 5,
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.adaptiveMinWait = 50 * time.Millisecond
+	p.adaptiveMaxWait = 800 * time.Millisecond
+
+	before := p.effectiveWaitTime()
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	afterChange := p.effectiveWaitTime()
+	if afterChange >= before {
+		t.Fatalf("wait time did not shrink after an observed change: before=%s after=%s", before, afterChange)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	afterQuiet := p.effectiveWaitTime()
+	if afterQuiet <= afterChange {
+		t.Fatalf("wait time did not grow during a quiet poll: afterChange=%s afterQuiet=%s", afterChange, afterQuiet)
+	}
+}
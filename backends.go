@@ -0,0 +1,25 @@
+package consul
+
+// currentKV returns the kvBackend currently in use by the watch loop,
+// guarded by protected.mutex. rotateAddress and applyNewToken can
+// replace it from the worker goroutine at any time, concurrently with
+// a caller-goroutine read from Get, Put or Keys (via readBackend), so
+// every read and write of r.kv must go through protected.mutex to
+// avoid a data race on the interface value itself.
+func (r *provider) currentKV() kvBackend {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return r.kv
+}
+
+// currentLeadershipBackends returns the session and lock KV backends
+// AcquireLeadership uses, guarded the same way as currentKV, since
+// AcquireLeadership can be called from any goroutine while the watch
+// loop is rotating addresses or refreshing its token.
+func (r *provider) currentLeadershipBackends() (sessionBackend, lockKVBackend) {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return r.session, r.lockKV
+}
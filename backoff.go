@@ -0,0 +1,67 @@
+package consul
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultBackoffBase = 5 * time.Second
+	defaultBackoffMax  = time.Minute
+)
+
+// backoff computes exponential reconnect delays with jitter, doubling
+// the delay on every call and resetting back to base after a success.
+type backoff struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func newBackoff(base, max time.Duration) *backoff {
+	if base <= 0 {
+		base = defaultBackoffBase
+	}
+
+	if max <= 0 {
+		max = defaultBackoffMax
+	}
+
+	return &backoff{base: base, max: max}
+}
+
+// reset returns the backoff to its base delay after a successful poll.
+func (b *backoff) reset() {
+	b.current = 0
+}
+
+// next returns the delay to wait before the next attempt and advances
+// the internal state for the following call.
+func (b *backoff) next() time.Duration {
+	if b.current == 0 {
+		b.current = b.base
+	} else {
+		b.current *= 2
+		if b.current > b.max {
+			b.current = b.max
+		}
+	}
+
+	// Full jitter: a random duration between 0 and the computed delay.
+	return time.Duration(rand.Int63n(int64(b.current) + 1))
+}
+
+// sleep waits for d or until ctx is cancelled, whichever happens first.
+// It returns ctx.Err() if the context was cancelled during the wait.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
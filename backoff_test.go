@@ -0,0 +1,64 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	b := newBackoff(10*time.Millisecond, 40*time.Millisecond)
+
+	for i, want := range []time.Duration{10, 20, 40, 40} {
+		d := b.next()
+		if d > want*time.Millisecond {
+			t.Fatalf("call %d: next() = %s, want <= %s", i, d, want*time.Millisecond)
+		}
+	}
+}
+
+func TestBackoffResets(t *testing.T) {
+	b := newBackoff(10*time.Millisecond, 40*time.Millisecond)
+
+	b.next()
+	b.next()
+	b.reset()
+
+	if d := b.next(); d > 10*time.Millisecond {
+		t.Fatalf("next() after reset = %s, want <= 10ms", d)
+	}
+}
+
+func TestSleepCancelledMidWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+
+	if err := sleep(ctx, 5*time.Second); err == nil {
+		t.Fatal("sleep did not return an error when the context was cancelled mid-wait")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("sleep took %s to react to cancellation, want well under 5s", elapsed)
+	}
+}
+
+func TestSleepRespectsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+
+	if err := sleep(ctx, time.Minute); err == nil {
+		t.Fatal("sleep did not return an error for a cancelled context")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("sleep took %s, want near-immediate return", elapsed)
+	}
+}
@@ -0,0 +1,34 @@
+package consul
+
+import "encoding/base64"
+
+// WithBinaryParam marks setName/paramName as holding binary data (e.g.
+// a certificate or key) rather than text. Its raw Consul value is
+// base64-encoded instead of being cast to a string directly, which
+// would otherwise corrupt bytes that aren't valid UTF-8 (in particular,
+// embedded null bytes silently truncate many C-backed string APIs).
+//
+// The application decodes the delivered value itself with
+// encoding/base64.StdEncoding, the same way it already does for any
+// other proteus string parameter that happens to carry structured
+// content.
+func WithBinaryParam(setName, paramName string) Option {
+	return func(p *provider) {
+		if p.binaryParams == nil {
+			p.binaryParams = map[string]bool{}
+		}
+
+		p.binaryParams[setName+"/"+paramName] = true
+	}
+}
+
+// encodeValue turns a decoded Consul value into the string delivered to
+// proteus, base64-encoding it first when setName/paramName was marked
+// with WithBinaryParam.
+func (r *provider) encodeValue(setName, paramName string, value []byte) string {
+	if r.binaryParams[setName+"/"+paramName] {
+		return base64.StdEncoding.EncodeToString(value)
+	}
+
+	return string(value)
+}
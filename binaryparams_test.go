@@ -0,0 +1,78 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListBase64EncodesBinaryParam(t *testing.T) {
+	raw := []byte{0x00, 0x01, 0xff, 'c', 'e', 'r', 't', 0x00, 'k', 'e', 'y'}
+
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/tls/cert", Value: raw},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"tls/cert": true})
+	p.binaryParams = map[string]bool{"tls/cert": true}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(ret["tls/cert"])
+	if err != nil {
+		t.Fatalf("tls/cert value is not valid base64: %v", err)
+	}
+
+	if string(decoded) != string(raw) {
+		t.Fatalf("decoded = %q, want %q", decoded, raw)
+	}
+}
+
+func TestListLeavesNonBinaryParamAsPlainString(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.binaryParams = map[string]bool{"tls/cert": true}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost unencoded", ret)
+	}
+}
+
+func TestWithBinaryParamMarksSetAndParam(t *testing.T) {
+	p := &provider{}
+
+	WithBinaryParam("tls", "cert")(p)
+
+	if !p.binaryParams["tls/cert"] {
+		t.Fatalf("binaryParams = %v, want tls/cert marked", p.binaryParams)
+	}
+}
+
+func TestEncodeValueRoundTripsNullBytes(t *testing.T) {
+	p := &provider{binaryParams: map[string]bool{"tls/key": true}}
+
+	raw := []byte("before\x00after")
+	encoded := p.encodeValue("tls", "key", raw)
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decoded) != string(raw) {
+		t.Fatalf("decoded = %q, want %q", decoded, raw)
+	}
+}
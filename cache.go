@@ -0,0 +1,49 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// saveCache persists ret to r.cacheFilePath as JSON, for WithCacheFile.
+// It's called after every successful poll, before the result is applied,
+// so the file reflects the last snapshot successfully read from Consul,
+// even one that the updater then rejected or that a debounce window
+// still holds back. Writes are best-effort: a
+// failure only logs, since losing the cache must never take down an
+// otherwise-healthy poll.
+func (r *provider) saveCache(ret types.ParamValues) {
+	if r.cacheFilePath == "" {
+		return
+	}
+
+	data, err := json.Marshal(ret)
+	if err != nil {
+		r.logDebug("consul: failed to marshal cache snapshot", String("error", err.Error()))
+		return
+	}
+
+	if err := os.WriteFile(r.cacheFilePath, data, 0o600); err != nil {
+		r.logDebug("consul: failed to write cache file", String("path", r.cacheFilePath), String("error", err.Error()))
+	}
+}
+
+// loadCache reads back the snapshot last written by saveCache, for
+// Watch to fall back on when the initial list fails and WithCacheFile
+// is configured.
+func (r *provider) loadCache() (types.ParamValues, error) {
+	data, err := os.ReadFile(r.cacheFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret types.ParamValues
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("consul: cache file %q contains invalid JSON: %w", r.cacheFilePath, err)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,127 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestSaveCacheThenLoadCacheRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	p := &provider{cacheFilePath: path}
+
+	p.saveCache(types.ParamValues{"db/host": "localhost", "db/port": "5432"})
+
+	got, err := p.loadCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["db/host"] != "localhost" || got["db/port"] != "5432" {
+		t.Fatalf("loadCache() = %+v, want the snapshot saveCache wrote", got)
+	}
+}
+
+func TestSaveCacheIsNoopWithoutCacheFilePath(t *testing.T) {
+	p := &provider{}
+
+	// Must not panic or try to write to an empty path.
+	p.saveCache(types.ParamValues{"db/host": "localhost"})
+}
+
+func TestLoadCachePropagatesMissingFile(t *testing.T) {
+	p := &provider{cacheFilePath: filepath.Join(t.TempDir(), "does-not-exist.json")}
+
+	if _, err := p.loadCache(); err == nil {
+		t.Fatal("loadCache() err = nil, want an error for a missing cache file")
+	}
+}
+
+func TestLoadCacheRejectsInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &provider{cacheFilePath: path}
+
+	if _, err := p.loadCache(); err == nil {
+		t.Fatal("loadCache() err = nil, want an error for a malformed cache file")
+	}
+}
+
+func TestWatchFallsBackToCacheFileWhenInitialListFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	seed, err := json.Marshal(types.ParamValues{"db/host": "stale.internal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, seed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New("http://127.0.0.1:8500", "app/", WithCacheFile(path)).(*provider)
+
+	updater := &recordingUpdater{}
+	if err := p.Watch(updater); err != nil {
+		t.Fatalf("Watch() err = %v, want the cache file fallback to make it succeed", err)
+	}
+	defer p.Stop()
+
+	if updater.calls != 1 {
+		t.Fatalf("updater calls = %d, want 1", updater.calls)
+	}
+
+	if updater.last["db/host"] != "stale.internal" {
+		t.Fatalf("updater.last = %+v, want the value seeded in the cache file", updater.last)
+	}
+}
+
+func TestWatchWithoutCacheFileStillFailsOnInitialListError(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app/").(*provider)
+
+	if err := p.Watch(&recordingUpdater{}); err == nil {
+		t.Fatal("Watch() err = nil, want the initial list failure to still surface without WithCacheFile")
+	}
+}
+
+func TestWatchWithCacheFileStillFailsWhenNoCacheExistsYet(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app/", WithCacheFile(filepath.Join(t.TempDir(), "cache.json"))).(*provider)
+
+	if err := p.Watch(&recordingUpdater{}); err == nil {
+		t.Fatal("Watch() err = nil, want an error when both the initial list and the cache fallback fail")
+	}
+}
+
+func TestListByPrefixSuccessCanBeCached(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.cacheFilePath = path
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.saveCache(ret)
+
+	got, err := p.loadCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["db/host"] != "localhost" {
+		t.Fatalf("cached snapshot = %+v, want db/host=localhost", got)
+	}
+}
@@ -0,0 +1,102 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+// catalogBackend abstracts the subset of Consul's Catalog HTTP API used
+// by NewFromCatalogServiceMeta. *consulapi.Catalog satisfies it
+// directly; tests can inject a fake implementation instead of requiring
+// a running Consul.
+type catalogBackend interface {
+	Service(service, tag string, q *consulapi.QueryOptions) ([]*consulapi.CatalogService, *consulapi.QueryMeta, error)
+}
+
+// listFromCatalog polls catalogService's registration via a blocking
+// catalog query and maps every ServiceMeta key/value pair across all of
+// its instances to a parameter under catalogSetName, the same way
+// listByPrefix maps KV pairs under a prefix. Instances registered later
+// in the response win when two instances disagree on a meta value,
+// mirroring listByPrefix's "later prefix wins" rule for duplicate keys.
+//
+// A transient error from the catalog query is retried the same way
+// fetchPrefix retries a KV list, up to listRetryAttempts with
+// listRetryDelay between attempts, instead of failing the whole poll.
+func (r *provider) listFromCatalog(ctx context.Context) (types.ParamValues, error) {
+	ret := types.ParamValues{}
+
+	if r.rateLimiter != nil {
+		if err := r.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	r.protected.mutex.Lock()
+	waitIx := r.protected.waitIx[r.catalogService]
+	r.protected.mutex.Unlock()
+
+	var services []*consulapi.CatalogService
+	var meta *consulapi.QueryMeta
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		services, meta, err = r.catalog.Service(r.catalogService, "", r.queryOptions(ctx, waitIx))
+		r.metrics.observePoll(start, err)
+
+		if err == nil || !isRetryableErr(err) || attempt >= r.listRetryAttempts() {
+			break
+		}
+
+		if sleepErr := r.sleep(ctx, r.listRetryDelay()); sleepErr != nil {
+			err = sleepErr
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	setName := r.catalogSetName
+
+	for _, svc := range services {
+		for metaKey, value := range svc.ServiceMeta {
+			fullKey := r.catalogService + "/" + metaKey
+
+			resolvedSet, paramName := r.normalizeKeyCase(setName, metaKey)
+
+			if r.keyFilter != nil && !r.keyFilter(resolvedSet, paramName) {
+				r.ignoreKey(fullKey, "filtered out by WithKeyFilter")
+				continue
+			}
+
+			if !r.paramNames.Get(resolvedSet, paramName) {
+				r.ignoreKey(fullKey, "no matching parameter")
+				continue
+			}
+
+			ret[resolvedSet+"/"+paramName] = value
+		}
+	}
+
+	newWaitIx := nextWaitIndex(waitIx, meta.LastIndex)
+
+	r.protected.mutex.Lock()
+	r.protected.waitIx[r.catalogService] = newWaitIx
+	r.protected.mutex.Unlock()
+
+	r.metrics.setWaitIndex(newWaitIx)
+	r.adjustAdaptiveWaitTime(meta.LastIndex != waitIx)
+	r.reportPollWake(r.catalogService, waitIx, meta)
+	r.logDebug("consul: polled catalog service",
+		String("service", r.catalogService),
+		String("datacenter", r.datacenter),
+		Uint64("index", newWaitIx))
+
+	return ret, nil
+}
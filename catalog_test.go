@@ -0,0 +1,122 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+type fakeCatalog struct {
+	services  []*consulapi.CatalogService
+	lastIndex uint64
+	err       error
+	failCount int
+	failErr   error
+}
+
+func (f *fakeCatalog) Service(_, _ string, _ *consulapi.QueryOptions) ([]*consulapi.CatalogService, *consulapi.QueryMeta, error) {
+	if f.failCount > 0 {
+		f.failCount--
+		return nil, nil, f.failErr
+	}
+
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	return f.services, &consulapi.QueryMeta{LastIndex: f.lastIndex}, nil
+}
+
+func newTestCatalogProvider(catalog catalogBackend, service, setName string, known map[string]bool) *provider {
+	p := &provider{
+		catalogMode:    true,
+		catalogService: service,
+		catalogSetName: setName,
+		catalog:        catalog,
+		paramNames:     stubParamNames{known: known},
+	}
+	p.protected.waitIx = make(map[string]uint64)
+
+	return p
+}
+
+func TestListFromCatalogMapsServiceMetaToParameters(t *testing.T) {
+	catalog := &fakeCatalog{
+		services: []*consulapi.CatalogService{
+			{ServiceMeta: map[string]string{"host": "localhost", "port": "5432"}},
+		},
+		lastIndex: 7,
+	}
+
+	p := newTestCatalogProvider(catalog, "db", "db", map[string]bool{"host": true, "port": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" || ret["db/port"] != "5432" {
+		t.Fatalf("ret = %+v, want db/host=localhost and db/port=5432", ret)
+	}
+}
+
+func TestListFromCatalogIgnoresUnknownMetaKey(t *testing.T) {
+	catalog := &fakeCatalog{
+		services: []*consulapi.CatalogService{
+			{ServiceMeta: map[string]string{"host": "localhost", "internal-build-id": "abc123"}},
+		},
+		lastIndex: 3,
+	}
+
+	var ignored []string
+	p := newTestCatalogProvider(catalog, "db", "db", map[string]bool{"host": true})
+	p.onIgnoredKey = func(fullKey, _ string) { ignored = append(ignored, fullKey) }
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != 1 || ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %+v, want only db/host=localhost", ret)
+	}
+
+	if len(ignored) != 1 || ignored[0] != "db/internal-build-id" {
+		t.Fatalf("ignored = %v, want [db/internal-build-id]", ignored)
+	}
+}
+
+func TestListFromCatalogPropagatesError(t *testing.T) {
+	catalog := &fakeCatalog{err: errListFailed}
+
+	p := newTestCatalogProvider(catalog, "db", "db", map[string]bool{"host": true})
+
+	if _, err := p.list(context.Background()); err != errListFailed {
+		t.Fatalf("err = %v, want %v", err, errListFailed)
+	}
+}
+
+func TestListFromCatalogRetriesTransientErrorThenSucceeds(t *testing.T) {
+	catalog := &fakeCatalog{
+		services:  []*consulapi.CatalogService{{ServiceMeta: map[string]string{"host": "localhost"}}},
+		failCount: 2,
+		failErr:   errors.New("Unexpected response code: 500 (internal error)"),
+	}
+
+	p := newTestCatalogProvider(catalog, "db", "db", map[string]bool{"host": true})
+	p.listRetries = 3
+	p.retryDelay = time.Hour
+	p.clock = newFakeClock(time.Now())
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %+v, want db/host=localhost", ret)
+	}
+}
@@ -0,0 +1,57 @@
+package consul
+
+import (
+	"time"
+
+	"github.com/simplesurance/proteus/sources"
+)
+
+// ClientConfig bundles the Consul connection settings that otherwise
+// accumulate as separate options (WithACLToken, WithDatacenter,
+// WithNamespace, WithPartition, WithTLS, WithTokenFile,
+// WithHTTPTimeout) into a single struct, for callers who'd rather pass
+// one config value than a long WithXxx list. It maps directly onto the
+// same provider fields buildConfig later reads to build a
+// consul.Config; the zero value changes nothing, the same as calling
+// none of the equivalent options.
+type ClientConfig struct {
+	// Address is the Consul HTTP API address, e.g.
+	// "http://127.0.0.1:8500".
+	Address string
+
+	Token       string
+	Datacenter  string
+	Namespace   string
+	Partition   string
+	TLS         TLSOptions
+	TokenFile   string
+	HTTPTimeout time.Duration
+}
+
+// NewWithConfig creates a provider like New, but takes its Consul
+// connection settings from cfg instead of a pile of WithXxx options.
+// The remaining, non-connection options (blocking-query tuning, key
+// parsing, callbacks, write access, ...) are still passed through opts
+// the usual way, and are applied after cfg, so an opt can still
+// override a field cfg also set.
+func NewWithConfig(cfg ClientConfig, prefix string, opts ...Option) sources.Provider {
+	allOpts := []Option{
+		WithACLToken(cfg.Token),
+		WithDatacenter(cfg.Datacenter),
+		WithNamespace(cfg.Namespace),
+		WithPartition(cfg.Partition),
+		WithTLS(cfg.TLS),
+	}
+
+	if cfg.TokenFile != "" {
+		allOpts = append(allOpts, WithTokenFile(cfg.TokenFile))
+	}
+
+	if cfg.HTTPTimeout > 0 {
+		allOpts = append(allOpts, WithHTTPTimeout(cfg.HTTPTimeout))
+	}
+
+	allOpts = append(allOpts, opts...)
+
+	return New(cfg.Address, prefix, allOpts...)
+}
@@ -0,0 +1,106 @@
+package consul
+
+import (
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestNewWithConfigMapsFieldsOntoProvider(t *testing.T) {
+	cfg := ClientConfig{
+		Address:     "http://127.0.0.1:8500",
+		Token:       "s3cr3t",
+		Datacenter:  "dc2",
+		Namespace:   "team-a",
+		Partition:   "billing",
+		TLS:         TLSOptions{CAFile: "ca.pem", InsecureSkipVerify: true},
+		TokenFile:   "/etc/consul/token",
+		HTTPTimeout: 5 * time.Second,
+	}
+
+	p := NewWithConfig(cfg, "app/").(*provider)
+
+	if p.token != cfg.Token {
+		t.Errorf("token = %q, want %q", p.token, cfg.Token)
+	}
+
+	if p.datacenter != cfg.Datacenter {
+		t.Errorf("datacenter = %q, want %q", p.datacenter, cfg.Datacenter)
+	}
+
+	if p.namespace != cfg.Namespace {
+		t.Errorf("namespace = %q, want %q", p.namespace, cfg.Namespace)
+	}
+
+	if p.partition != cfg.Partition {
+		t.Errorf("partition = %q, want %q", p.partition, cfg.Partition)
+	}
+
+	if p.tls != cfg.TLS {
+		t.Errorf("tls = %+v, want %+v", p.tls, cfg.TLS)
+	}
+
+	if p.tokenFile != cfg.TokenFile {
+		t.Errorf("tokenFile = %q, want %q", p.tokenFile, cfg.TokenFile)
+	}
+
+	if p.httpTimeout != cfg.HTTPTimeout {
+		t.Errorf("httpTimeout = %q, want %q", p.httpTimeout, cfg.HTTPTimeout)
+	}
+}
+
+func TestNewWithConfigBuildsConsulConfigCorrectly(t *testing.T) {
+	cfg := ClientConfig{
+		Address:    "https://127.0.0.1:8501",
+		Token:      "s3cr3t",
+		Datacenter: "dc2",
+		TLS:        TLSOptions{CAFile: "ca.pem", InsecureSkipVerify: true},
+	}
+
+	p := NewWithConfig(cfg, "app/").(*provider)
+
+	consulCfg, err := p.buildConfig(cfg.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if consulCfg.Token != cfg.Token {
+		t.Errorf("Token = %q, want %q", consulCfg.Token, cfg.Token)
+	}
+
+	if consulCfg.TLSConfig.CAFile != cfg.TLS.CAFile || consulCfg.TLSConfig.InsecureSkipVerify != cfg.TLS.InsecureSkipVerify {
+		t.Errorf("TLSConfig = %+v, want CAFile=%q InsecureSkipVerify=%v", consulCfg.TLSConfig, cfg.TLS.CAFile, cfg.TLS.InsecureSkipVerify)
+	}
+}
+
+func TestNewWithConfigZeroValueChangesNothing(t *testing.T) {
+	p := NewWithConfig(ClientConfig{Address: "http://127.0.0.1:8500"}, "app/").(*provider)
+
+	cfg, err := p.buildConfig("http://127.0.0.1:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Token != "" {
+		t.Errorf("Token = %q, want empty for a zero-value ClientConfig", cfg.Token)
+	}
+
+	if cfg.TLSConfig != (consulapi.TLSConfig{}) {
+		t.Errorf("TLSConfig = %+v, want zero value for a zero-value ClientConfig", cfg.TLSConfig)
+	}
+
+	if p.httpTimeout != 0 {
+		t.Errorf("httpTimeout = %s, want zero", p.httpTimeout)
+	}
+}
+
+func TestNewWithConfigOptsOverrideConfigFields(t *testing.T) {
+	cfg := ClientConfig{Address: "http://127.0.0.1:8500", Token: "from-config"}
+
+	p := NewWithConfig(cfg, "app/", WithACLToken("from-opt")).(*provider)
+
+	if p.token != "from-opt" {
+		t.Errorf("token = %q, want the WithACLToken opt to win over ClientConfig.Token", p.token)
+	}
+}
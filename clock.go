@@ -0,0 +1,50 @@
+package consul
+
+import (
+	"context"
+	"time"
+)
+
+// clock abstracts time.Now and time.After behind an interface so tests
+// can inject a fake implementation that advances instantly instead of
+// waiting in real time. A zero-value provider has no clock configured
+// and falls back to realClock through clockOrDefault, so this is purely
+// a test seam - there is no WithClock option.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the default clock, backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clockOrDefault returns r.clock, or realClock{} when none was
+// injected, so production code paths never need to special-case a nil
+// clock.
+func (r *provider) clockOrDefault() clock {
+	if r.clock != nil {
+		return r.clock
+	}
+
+	return realClock{}
+}
+
+// sleep waits for d, measured by r.clockOrDefault(), or until ctx is
+// cancelled, whichever happens first. It returns ctx.Err() if the
+// context was cancelled during the wait. This is the provider-aware
+// counterpart to the package-level sleep function, used everywhere
+// updateWorkerIteration and applyDebouncedChange back off after a
+// failure, and for the list retry delay and initialList's backoff, so
+// that tests can substitute a fake clock instead of waiting in real
+// time.
+func (r *provider) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.clockOrDefault().After(d):
+		return nil
+	}
+}
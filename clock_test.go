@@ -0,0 +1,41 @@
+package consul
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeClock is a clock whose Now() only advances when told to, so
+// backoff and debounce logic can be driven through elapsed-time
+// decisions without a test actually waiting in real time. After returns
+// an already-fired channel, since these tests advance Now() explicitly
+// instead of relying on a real timer.
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newFakeClock(start time.Time) *fakeClock {
+	return &fakeClock{now: start}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) After(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+
+	return ch
+}
@@ -0,0 +1,90 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// xorCodec is a trivial, insecure "encryption" codec for tests: it
+// XORs every byte with key, which also makes it self-inverse.
+func xorCodec(key byte) func([]byte) ([]byte, error) {
+	return func(value []byte) ([]byte, error) {
+		out := make([]byte, len(value))
+		for i, b := range value {
+			out[i] = b ^ key
+		}
+
+		return out, nil
+	}
+}
+
+func xorEncode(plain string, key byte) []byte {
+	out := make([]byte, len(plain))
+	for i := 0; i < len(plain); i++ {
+		out[i] = plain[i] ^ key
+	}
+
+	return out
+}
+
+func TestListDecodesValueWithXORCodec(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: xorEncode("localhost", 0x5a)},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.valueDecoder = nil
+	WithValueCodec(xorCodec(0x5a))(p)
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListSkipsKeyWhenCodecFails(t *testing.T) {
+	errCodecFailed := errors.New("codec failed")
+
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("garbage")},
+		{Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	WithValueCodec(func(value []byte) ([]byte, error) {
+		if string(value) == "garbage" {
+			return nil, errCodecFailed
+		}
+
+		return value, nil
+	})(p)
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey)
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ret["db/host"]; ok {
+		t.Fatal("list() kept a value that failed to decode via the codec")
+	}
+
+	if ret["db/port"] != "5432" {
+		t.Fatalf("ret = %v, want db/port=5432", ret)
+	}
+
+	if len(ignored) != 1 || ignored[0] != "app/db/host" {
+		t.Fatalf("ignored = %v, want app/db/host reported", ignored)
+	}
+}
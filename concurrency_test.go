@@ -0,0 +1,120 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// TestConcurrentAccessorsWhileWorkerPolls exercises Snapshot, Status,
+// Healthy, Err, LastModifyIndex and SetPrefix from goroutines other than
+// the update worker while it keeps polling a fakeKV, so that `go test
+// -race` catches any provider state read or mutated outside
+// protected.mutex. It doesn't assert on the values returned - only that
+// nothing races.
+func TestConcurrentAccessorsWhileWorkerPolls(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.updater = &recordingUpdater{}
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := runWorker(p, ctx)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	accessors := []func(){
+		func() { p.Snapshot() },
+		func() { p.Status() },
+		func() { p.Healthy() },
+		func() { p.Err() },
+		func() { p.LastModifyIndex("db", "host") },
+		func() { p.SetPrefix("app/") },
+		func() { _ = p.Refresh(ctx) },
+	}
+
+	for _, accessor := range accessors {
+		wg.Add(1)
+
+		go func(fn func()) {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					fn()
+				}
+			}
+		}(accessor)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	cancel()
+	waitOrTimeout(t, stopped, time.Second)
+}
+
+// TestConcurrentGetPutDuringAddressRotation exercises Get and Put from
+// goroutines other than the update worker while the worker repeatedly
+// triggers rotateAddress (via WithFallbackAddresses's failure
+// threshold), so `go test -race` catches r.client/kv/session/lockKV/
+// txnKV/catalog/agent being swapped without protected.mutex while a
+// concurrent Get/Put reads them.
+func TestConcurrentGetPutDuringAddressRotation(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.updater = &recordingUpdater{}
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+	p.writable = true
+	p.protected.addresses = []string{"http://127.0.0.1:1", "http://127.0.0.1:2"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := runWorker(p, ctx)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	accessors := []func(){
+		func() { _, _, _ = p.Get(context.Background(), "db", "host") },
+		func() { _ = p.Put(context.Background(), "db", "host", "x") },
+	}
+
+	for _, accessor := range accessors {
+		wg.Add(1)
+
+		go func(fn func()) {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					fn()
+				}
+			}
+		}(accessor)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	cancel()
+	waitOrTimeout(t, stopped, time.Second)
+}
@@ -0,0 +1,18 @@
+package consul
+
+// logConstructionWarnings reports configuration mistakes that are
+// detectable at construction time, without contacting Consul, so a
+// caller who passed WithLogger sees them immediately instead of
+// discovering the effect (or its absence) only once Watch starts
+// polling. It relies entirely on r.logger, so it's a silent no-op for
+// a provider constructed without WithLogger, same as every other
+// logInfo call.
+func (r *provider) logConstructionWarnings() {
+	if r.nestedKeys && r.maxKeyDepth > 0 {
+		r.logInfo("consul: WARNING WithNestedKeys has no effect because WithMaxKeyDepth is also set; WithMaxKeyDepth's cap takes precedence")
+	}
+
+	if r.catalogMode && r.perKeyWatchKeys != nil {
+		r.logInfo("consul: WARNING WithPerKeyWatch has no effect on a provider created with NewFromCatalogServiceMeta")
+	}
+}
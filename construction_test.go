@@ -0,0 +1,58 @@
+package consul
+
+import "testing"
+
+func TestNewLogsWarningForConflictingKeyDepthOptions(t *testing.T) {
+	logger := &capturingLogger{}
+
+	New("http://127.0.0.1:8500", "app/",
+		WithLogger(logger),
+		WithNestedKeys(),
+		WithMaxKeyDepth(3),
+	)
+
+	found := false
+	for _, msg := range logger.infoMsgs {
+		if msg == "consul: WARNING WithNestedKeys has no effect because WithMaxKeyDepth is also set; WithMaxKeyDepth's cap takes precedence" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("infoMsgs = %v, want a construction-time warning about WithNestedKeys/WithMaxKeyDepth", logger.infoMsgs)
+	}
+}
+
+func TestNewLogsNoWarningWithoutConflictingOptions(t *testing.T) {
+	logger := &capturingLogger{}
+
+	New("http://127.0.0.1:8500", "app/", WithLogger(logger))
+
+	if len(logger.infoMsgs) != 0 {
+		t.Fatalf("infoMsgs = %v, want no construction-time warnings for a plain config", logger.infoMsgs)
+	}
+}
+
+func TestNewFromCatalogServiceMetaWarnsAboutPerKeyWatch(t *testing.T) {
+	logger := &capturingLogger{}
+
+	NewFromCatalogServiceMeta("http://127.0.0.1:8500", "myservice", "app",
+		WithLogger(logger),
+		WithPerKeyWatch("db/host"),
+	)
+
+	found := false
+	for _, msg := range logger.infoMsgs {
+		if msg == "consul: WARNING WithPerKeyWatch has no effect on a provider created with NewFromCatalogServiceMeta" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("infoMsgs = %v, want a construction-time warning about WithPerKeyWatch", logger.infoMsgs)
+	}
+}
+
+func TestNewWithoutLoggerDoesNotPanicOnConstructionWarnings(t *testing.T) {
+	New("http://127.0.0.1:8500", "app/", WithNestedKeys(), WithMaxKeyDepth(3))
+}
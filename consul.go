@@ -0,0 +1,349 @@
+// Package consul implements a proteus configuration source that reads
+// parameters from a HashiCorp Consul key/value store.
+//
+// A provider watches a KV prefix using Consul's blocking queries and
+// pushes updated values into proteus whenever a key under the prefix
+// changes.
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/sources"
+	"github.com/simplesurance/proteus/types"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// ParameterReferences lets connection settings that a provider needs be
+// supplied by another proteus provider instead of being hardcoded at
+// construction time.
+type ParameterReferences struct {
+	// ConsulURL references the parameter that holds the Consul HTTP
+	// API address, e.g. "http://127.0.0.1:8500".
+	ConsulURL *sources.Reference
+
+	// Prefix references the parameter that holds the watched KV
+	// prefix, resolved in Watch alongside ConsulURL. An empty
+	// resolved value falls back to the prefix passed to
+	// NewFromReference, so the reference is optional even when set.
+	Prefix *sources.Reference
+}
+
+// provider is a proteus source that watches a Consul KV prefix and
+// reports the parameters found under it.
+type provider struct {
+	consulURLFn              func() (string, error)
+	prefixFn                 func() string
+	additionalPrefixes       []string
+	setPrefixes              []setPrefixMapping
+	federatedDatacenters     []string
+	wildcardParams           []wildcardParam
+	binaryParams             map[string]bool
+	dotKeysDisabled          bool
+	nonRecursive             bool
+	token                    string
+	datacenter               string
+	tls                      TLSOptions
+	namespace                string
+	namespaceRef             *sources.Reference
+	partition                string
+	partitionRef             *sources.Reference
+	waitTime                 time.Duration
+	backoffBase              time.Duration
+	backoffMax               time.Duration
+	onUpdate                 func(types.ParamValues)
+	metrics                  *metrics
+	nestedKeys               bool
+	keySeparator             string
+	caseInsensitive          bool
+	startupTimeout           time.Duration
+	tokenFile                string
+	cacheFilePath            string
+	jsonValues               bool
+	consistencyMode          ConsistencyMode
+	emptyValuePolicy         EmptyValuePolicy
+	onIgnoredKey             func(fullKey, reason string)
+	writable                 bool
+	keyFilter                func(setName, paramName string) bool
+	httpAuth                 *consulapi.HttpBasicAuth
+	httpHeaders              map[string]string
+	httpTimeout              time.Duration
+	transportTuning          *TransportOptions
+	perKeyWatchKeys          []string
+	baseCtx                  context.Context
+	tokenProvider            TokenProvider
+	strictUnknownKeys        bool
+	listRetries              int
+	retryDelay               time.Duration
+	valueDecoder             func([]byte) ([]byte, error)
+	startupJitter            time.Duration
+	onPollWake               func(PollWakeInfo)
+	keyTransform             func(rawKey string) (setName, paramName string, ok bool)
+	debounce                 time.Duration
+	excludePrefixes          []string
+	tracerProvider           trace.TracerProvider
+	onDelta                  func(added, changed, removed []KeyRef)
+	onKeyModified            func(KeyModifiedEvent)
+	rateLimiter              *rate.Limiter
+	txnRead                  bool
+	logger                   Logger
+	fallbackAddresses        []string
+	adaptiveMinWait          time.Duration
+	adaptiveMaxWait          time.Duration
+	onEmptyPrefix            func()
+	envDefaults              bool
+	maxKeyDepth              int
+	catalogMode              bool
+	catalogService           string
+	catalogSetName           string
+	deferInitialApply        bool
+	valueTemplating          bool
+	maxConsecutiveFailures   int
+	onMaxConsecutiveFailures func(error)
+	stripSegments            int
+
+	// clock is a test seam only, see clockOrDefault; production
+	// providers always leave it nil and get the real time package.
+	clock clock
+
+	client *consulapi.Client
+	kv     kvBackend
+
+	// readReplicaAddr, when set by WithReadReplica, is used to build a
+	// second client (readKV) for Get and Keys, so high-volume ad-hoc
+	// reads don't compete with the watch loop's blocking queries on the
+	// same connection.
+	readReplicaAddr string
+	readKV          kvBackend
+
+	session sessionBackend
+	lockKV  lockKVBackend
+	txnKV   kvTxnBackend
+	catalog catalogBackend
+	agent   agentBackend
+
+	paramNames sources.ParamNames
+	updater    sources.Updater
+
+	// updateMu serializes calls into updater.Update and the bookkeeping
+	// that follows it, so a caller-triggered Refresh can't race with the
+	// update worker's own poll.
+	updateMu sync.Mutex
+
+	stopFn  func()
+	stopped *sync.WaitGroup
+
+	protected struct {
+		mutex           sync.Mutex
+		started         bool
+		prefix          string
+		waitIx          map[string]uint64
+		lastErr         error
+		lastUpdateTime  time.Time
+		lastApplied     types.ParamValues
+		modifyIndex     map[string]uint64
+		unknownKeys     []string
+		pendingUpdate   types.ParamValues
+		pendingDeadline time.Time
+
+		// addresses, activeAddrIx and consecutiveFailures back
+		// WithFallbackAddresses's address rotation, see failover.go.
+		addresses           []string
+		activeAddrIx        int
+		consecutiveFailures int
+
+		// workerErr is the last poll error the update worker saw
+		// before it stopped, unless it stopped because of Stop
+		// itself. See Err.
+		workerErr error
+
+		// adaptiveWaitTime is the current WaitTime chosen by
+		// WithAdaptiveWaitTime, see adjustAdaptiveWaitTime.
+		adaptiveWaitTime time.Duration
+
+		// rawPairCount and rawQueryIndex are the number of KV pairs
+		// and the Consul index the last listByPrefix call saw before
+		// any filtering, used by checkEmptyPrefix to warn about a
+		// misconfigured prefix. See emptyprefix.go.
+		rawPairCount  int
+		rawQueryIndex uint64
+
+		// ignoredKeyCount counts ignoreKey calls made during the
+		// current list() call, reset at its start and reported to
+		// the WithMetrics ignored-keys gauge at its end.
+		ignoredKeyCount int
+
+		// maxFailuresNotified tracks whether
+		// onMaxConsecutiveFailures has already fired for the current
+		// run of consecutive failures, so it's called exactly once
+		// per outage instead of on every failed attempt past the
+		// threshold. It resets alongside consecutiveFailures on the
+		// next successful poll.
+		maxFailuresNotified bool
+
+		// serverVersion is the Consul version discovered by Watch's
+		// initial reportServerVersion call. See ServerVersion.
+		serverVersion string
+	}
+}
+
+// NewFromReference creates a provider whose Consul URL is resolved from
+// another provider's parameter when Watch is called. prefix is used as
+// the watched KV prefix, unless refs.Prefix is set and resolves to a
+// non-empty value, in which case that takes precedence.
+func NewFromReference(refs ParameterReferences, prefix string, opts ...Option) sources.Provider {
+	p := &provider{
+		consulURLFn: func() (string, error) {
+			return parametersFromReference(refs)
+		},
+		stopFn: func() {},
+	}
+	p.protected.prefix = normalizePrefix(prefix)
+
+	if refs.Prefix != nil {
+		p.prefixFn = refs.Prefix.Value
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.logConstructionWarnings()
+
+	return p
+}
+
+// New creates a provider that connects directly to the Consul HTTP API
+// at consulURI and watches keys under prefix.
+//
+// Use this constructor when the application already knows its Consul
+// address at startup. Use NewFromReference when the address must be
+// chained from another proteus provider.
+func New(consulURI, prefix string, opts ...Option) sources.Provider {
+	p := &provider{
+		consulURLFn: func() (string, error) {
+			return consulURI, nil
+		},
+		stopFn: func() {},
+	}
+	p.protected.prefix = normalizePrefix(prefix)
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.logConstructionWarnings()
+
+	return p
+}
+
+// NewWithClient creates a provider that watches keys under prefix using
+// an already-configured *consulapi.Client, instead of having the
+// provider build one from a URL and the WithXxx connection options.
+//
+// This decouples client construction from the provider, so a caller
+// that already needs custom transport, tracing or token renewal on its
+// Consul client can reuse it here as-is. Because client is used
+// verbatim, WithACLToken, WithTLS, WithNamespaceReference,
+// WithTokenFile and WithTokenProvider have no effect on a provider
+// created this way: there is no consulURLFn to reconnect through, so an
+// ACL error simply fails the poll instead of rotating the token (see
+// activeAddress). Token renewal has to happen on client itself, outside
+// this package. The remaining options (WithDatacenter and WithNamespace,
+// which are set per query, blocking-query tuning, key parsing,
+// callbacks, write access, ...) still apply.
+func NewWithClient(client *consulapi.Client, prefix string, opts ...Option) sources.Provider {
+	p := &provider{
+		client: client,
+		stopFn: func() {},
+	}
+	p.protected.prefix = normalizePrefix(prefix)
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.logConstructionWarnings()
+
+	return p
+}
+
+// NewFromCatalogServiceMeta creates a provider that reads parameter
+// values from a Consul catalog service's ServiceMeta instead of from a
+// KV prefix, for configuration that naturally lives on the service
+// registration itself (e.g. set by the deployment tooling that
+// registers the service). Every ServiceMeta key is treated as a
+// paramName under setName, using the same paramNames/keyFilter
+// matching and WithOnIgnoredKey reporting as the KV path.
+//
+// It reuses the same blocking-query polling, debounce and
+// update-worker machinery as New and NewFromReference; only how a poll
+// is turned into a types.ParamValues differs. WithPerKeyWatch and the
+// prefix-only options (WithNestedKeys, WithKeySeparator,
+// WithExcludePrefixes, WithMaxKeyDepth, ...) have no effect on a
+// provider created this way, since there is no KV prefix to derive
+// set/param names from.
+func NewFromCatalogServiceMeta(consulURI, service, setName string, opts ...Option) sources.Provider {
+	p := &provider{
+		consulURLFn: func() (string, error) {
+			return consulURI, nil
+		},
+		catalogMode:    true,
+		catalogService: service,
+		catalogSetName: setName,
+		stopFn:         func() {},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.logConstructionWarnings()
+
+	return p
+}
+
+// ErrConsulURINotProvided is returned by NewFromReference's deferred
+// address resolution when refs.ConsulURL is nil, so callers can
+// distinguish a missing reference from other kinds of init failure with
+// errors.Is instead of matching on the error string.
+var ErrConsulURINotProvided = errors.New("consul: ConsulURL reference is nil")
+
+func parametersFromReference(refs ParameterReferences) (string, error) {
+	if refs.ConsulURL == nil {
+		return "", fmt.Errorf("%w", ErrConsulURINotProvided)
+	}
+
+	return validateConsulURI(refs.ConsulURL.Value())
+}
+
+// validateConsulURI trims whitespace from a resolved ConsulURL
+// reference value and rejects an empty result the same way a nil
+// reference is rejected, since consulapi.Config{Address: ""} would
+// otherwise fail later with a much less obvious error.
+func validateConsulURI(raw string) (string, error) {
+	addr := strings.TrimSpace(raw)
+	if addr == "" {
+		return "", fmt.Errorf("%w: referenced value is empty", ErrConsulURINotProvided)
+	}
+
+	return addr, nil
+}
+
+// normalizePrefix ensures prefix ends in a single trailing slash so that
+// key derivation in list() can rely on strings.TrimPrefix producing a
+// clean set/param path.
+func normalizePrefix(prefix string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+
+	return prefix + "/"
+}
@@ -0,0 +1,34 @@
+package consul
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParametersFromReferenceNilURLIsErrConsulURINotProvided(t *testing.T) {
+	_, err := parametersFromReference(ParameterReferences{})
+	if !errors.Is(err, ErrConsulURINotProvided) {
+		t.Fatalf("err = %v, want errors.Is match against ErrConsulURINotProvided", err)
+	}
+}
+
+func TestValidateConsulURIRejectsEmptyValue(t *testing.T) {
+	if _, err := validateConsulURI(""); !errors.Is(err, ErrConsulURINotProvided) {
+		t.Fatalf("err = %v, want errors.Is match against ErrConsulURINotProvided", err)
+	}
+
+	if _, err := validateConsulURI("   "); !errors.Is(err, ErrConsulURINotProvided) {
+		t.Fatalf("err = %v, want errors.Is match against ErrConsulURINotProvided for whitespace-only value", err)
+	}
+}
+
+func TestValidateConsulURITrimsWhitespace(t *testing.T) {
+	addr, err := validateConsulURI("  http://127.0.0.1:8500  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr != "http://127.0.0.1:8500" {
+		t.Fatalf("addr = %q, want trimmed value", addr)
+	}
+}
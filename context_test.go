@@ -0,0 +1,106 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestWithContextCancellationStopsWorker(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}, lastIndex: 1}
+
+	updater := &countingUpdater{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+		baseCtx:    ctx,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	workerCtx, workerCancel := context.WithCancel(p.baseCtx)
+	defer workerCancel()
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(workerCtx, &stopped)
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		stopped.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("updateWorker did not exit after external context was cancelled")
+	}
+}
+
+// blockingKV simulates a real Consul blocking query: List doesn't
+// return until the QueryOptions' context is done, the same way the
+// real HTTP client would only abort mid-request once its context is
+// cancelled. fakeKV can't stand in for this - it ignores QueryOptions
+// entirely and returns immediately.
+type blockingKV struct {
+	started  chan struct{}
+	returned chan struct{}
+}
+
+func (b *blockingKV) List(_ string, q *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	close(b.started)
+	<-q.Context().Done()
+	close(b.returned)
+
+	return nil, nil, q.Context().Err()
+}
+
+func (b *blockingKV) Get(_ string, q *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error) {
+	<-q.Context().Done()
+	return nil, nil, q.Context().Err()
+}
+
+func (b *blockingKV) Put(_ *consulapi.KVPair, _ *consulapi.WriteOptions) (*consulapi.WriteMeta, error) {
+	return &consulapi.WriteMeta{}, nil
+}
+
+func TestContextCancellationAbortsInFlightBlockingList(t *testing.T) {
+	kv := &blockingKV{started: make(chan struct{}), returned: make(chan struct{})}
+
+	p := &provider{
+		kv:         kv,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() { _, _ = p.list(ctx) }()
+
+	select {
+	case <-kv.started:
+	case <-time.After(time.Second):
+		t.Fatal("kv.List was never called")
+	}
+
+	cancel()
+
+	select {
+	case <-kv.returned:
+	case <-time.After(time.Second):
+		t.Fatal("kv.List's in-flight call did not return promptly after ctx was cancelled - queryOptions is not tying the blocking query to the caller's context, so cancellation just abandons the goroutine instead of aborting the request")
+	}
+}
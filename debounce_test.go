@@ -0,0 +1,134 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// burstyKV replays a fixed sequence of KV snapshots, advancing to the
+// next one on every List call until the last is reached, then holding
+// there. It simulates a burst of rapid Consul writes without needing a
+// background goroutine racing the worker.
+type burstyKV struct {
+	mutex     sync.Mutex
+	snapshots []consulapi.KVPairs
+	indexes   []uint64
+	pos       int
+}
+
+func (f *burstyKV) List(prefix string, _ *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.pos < len(f.snapshots)-1 {
+		f.pos++
+	}
+
+	var matched consulapi.KVPairs
+
+	for _, pair := range f.snapshots[f.pos] {
+		if len(pair.Key) >= len(prefix) && pair.Key[:len(prefix)] == prefix {
+			matched = append(matched, pair)
+		}
+	}
+
+	return matched, &consulapi.QueryMeta{LastIndex: f.indexes[f.pos]}, nil
+}
+
+func (f *burstyKV) Get(string, *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error) {
+	return nil, nil, errListFailed
+}
+
+func (f *burstyKV) Put(*consulapi.KVPair, *consulapi.WriteOptions) (*consulapi.WriteMeta, error) {
+	return nil, errListFailed
+}
+
+func TestUpdateWorkerDebounceCoalescesBurst(t *testing.T) {
+	kv := &burstyKV{
+		snapshots: []consulapi.KVPairs{
+			{{Key: "app/db/host", Value: []byte("host1")}},
+			{{Key: "app/db/host", Value: []byte("host2")}},
+			{{Key: "app/db/host", Value: []byte("host3")}},
+		},
+		indexes: []uint64{1, 2, 3},
+	}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+		debounce:   50 * time.Millisecond,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for updater.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Give the worker a little longer to notice a (bug-induced) second
+	// Update before concluding the burst was really coalesced.
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	stopped.Wait()
+
+	if got := updater.callCount(); got != 1 {
+		t.Fatalf("Update called %d times for a burst inside the debounce window, want 1", got)
+	}
+}
+
+func TestUpdateWorkerDebounceDeliversFinalState(t *testing.T) {
+	kv := &burstyKV{
+		snapshots: []consulapi.KVPairs{
+			{{Key: "app/db/host", Value: []byte("host1")}},
+			{{Key: "app/db/host", Value: []byte("host2")}},
+			{{Key: "app/db/host", Value: []byte("host3")}},
+		},
+		indexes: []uint64{1, 2, 3},
+	}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+		debounce:   50 * time.Millisecond,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for updater.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	stopped.Wait()
+
+	p.protected.mutex.Lock()
+	applied := p.protected.lastApplied
+	p.protected.mutex.Unlock()
+
+	if applied["db/host"] != "host3" {
+		t.Fatalf("lastApplied = %v, want the final snapshot host3", applied)
+	}
+}
@@ -0,0 +1,49 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// TestApplyDebouncedChangeUsesInjectedClock drives WithDebounce's
+// pending-deadline logic through a fake clock instead of a real
+// time.Sleep, so the test completes instantly regardless of the
+// configured debounce window.
+func TestApplyDebouncedChangeUsesInjectedClock(t *testing.T) {
+	p := newTestProvider(&fakeKV{}, map[string]bool{"db/host": true})
+	updater := &recordingUpdater{}
+	p.updater = updater
+	p.debounce = time.Minute
+
+	fc := newFakeClock(time.Unix(0, 0))
+	p.clock = fc
+
+	backoff := newBackoff(time.Millisecond, time.Millisecond)
+	ctx := context.Background()
+	ret := types.ParamValues{"db/host": "localhost"}
+
+	if stop := p.applyDebouncedChange(ctx, backoff, ret); stop {
+		t.Fatal("applyDebouncedChange reported stop on first call")
+	}
+
+	if updater.calls != 0 {
+		t.Fatalf("calls = %d, want 0 before the debounce window elapses", updater.calls)
+	}
+
+	fc.Advance(2 * time.Minute)
+
+	if stop := p.applyDebouncedChange(ctx, backoff, ret); stop {
+		t.Fatal("applyDebouncedChange reported stop on second call")
+	}
+
+	if updater.calls != 1 {
+		t.Fatalf("calls = %d, want 1 once the debounce window has elapsed", updater.calls)
+	}
+
+	if updater.last["db/host"] != "localhost" {
+		t.Fatalf("delivered value = %+v, want db/host=localhost", updater.last)
+	}
+}
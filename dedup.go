@@ -0,0 +1,32 @@
+package consul
+
+import (
+	"sort"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// paramValuesEqual reports whether a and b contain exactly the same
+// set/param keys mapped to the same values. Comparison is deterministic
+// (sorted keys) so it doesn't depend on map iteration order.
+func paramValuesEqual(a, b types.ParamValues) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		bv, ok := b[k]
+		if !ok || a[k] != bv {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,145 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/sources"
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestParamValuesEqual(t *testing.T) {
+	a := types.ParamValues{"db/host": "localhost"}
+	b := types.ParamValues{"db/host": "localhost"}
+
+	if !paramValuesEqual(a, b) {
+		t.Fatal("equal maps reported as different")
+	}
+
+	b["db/host"] = "otherhost"
+	if paramValuesEqual(a, b) {
+		t.Fatal("different values reported as equal")
+	}
+}
+
+func TestParamValuesEqualMissingKeyWithEmptyValue(t *testing.T) {
+	a := types.ParamValues{"db/host": ""}
+	b := types.ParamValues{}
+
+	if paramValuesEqual(a, b) {
+		t.Fatal("map missing a key must not equal a map with that key set to \"\"")
+	}
+}
+
+// countingUpdater records every call to Update so tests can assert how
+// many times it was invoked.
+type countingUpdater struct {
+	mutex sync.Mutex
+	calls int
+}
+
+func (u *countingUpdater) Update(types.ParamValues) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.calls++
+
+	return nil
+}
+
+func (u *countingUpdater) Parameters() sources.ParamNames {
+	return nil
+}
+
+func (u *countingUpdater) callCount() int {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	return u.calls
+}
+
+// panicOnceUpdater panics on its first call, then behaves like a
+// normal counting updater afterward.
+type panicOnceUpdater struct {
+	countingUpdater
+	panicked bool
+}
+
+func (u *panicOnceUpdater) Update(v types.ParamValues) error {
+	if !u.panicked {
+		u.panicked = true
+		panic("boom")
+	}
+
+	return u.countingUpdater.Update(v)
+}
+
+func TestUpdateWorkerSkipsUpdateOnUnchangedPoll(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		lastIndex: 1,
+	}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+	p.protected.lastApplied = types.ParamValues{"db/host": "localhost"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	stopped.Wait()
+
+	if got := updater.callCount(); got != 0 {
+		t.Fatalf("Update called %d times for an unchanged poll, want 0", got)
+	}
+}
+
+func TestUpdateWorkerRecoversFromPanicInUpdate(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		lastIndex: 1,
+	}
+	updater := &panicOnceUpdater{}
+	p := &provider{
+		kv:          kv,
+		updater:     updater,
+		paramNames:  stubParamNames{known: map[string]bool{"db/host": true}},
+		backoffBase: time.Millisecond,
+		backoffMax:  2 * time.Millisecond,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	deadline := time.Now().Add(time.Second)
+	for updater.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	stopped.Wait()
+
+	if got := updater.callCount(); got != 1 {
+		t.Fatalf("Update succeeded %d times after the recovered panic, want 1 (worker must keep running)", got)
+	}
+}
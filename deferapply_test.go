@@ -0,0 +1,80 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/sources"
+	"github.com/simplesurance/proteus/types"
+)
+
+// recordingUpdater records the last value passed to Update, so tests
+// can assert on delivered values rather than just the call count.
+type recordingUpdater struct {
+	mutex sync.Mutex
+	last  types.ParamValues
+	calls int
+}
+
+func (u *recordingUpdater) Update(v types.ParamValues) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.last = v
+	u.calls++
+
+	return nil
+}
+
+func (u *recordingUpdater) Parameters() sources.ParamNames { return nil }
+
+func TestInitialUpdateValueDefersToEmptySet(t *testing.T) {
+	p := &provider{deferInitialApply: true}
+
+	got := p.initialUpdateValue(types.ParamValues{"db/host": "localhost"})
+	if len(got) != 0 {
+		t.Fatalf("initialUpdateValue = %+v, want an empty set", got)
+	}
+}
+
+func TestInitialUpdateValuePassesThroughByDefault(t *testing.T) {
+	p := &provider{}
+
+	ret := types.ParamValues{"db/host": "localhost"}
+
+	got := p.initialUpdateValue(ret)
+	if len(got) != 1 || got["db/host"] != "localhost" {
+		t.Fatalf("initialUpdateValue = %+v, want ret unchanged", got)
+	}
+}
+
+func TestDeferredInitialValuesArriveOnFirstWorkerPoll(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}, lastIndex: 5}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.deferInitialApply = true
+
+	updater := &recordingUpdater{}
+	p.updater = updater
+
+	// Mirrors what Watch does under WithDeferInitialApply: apply an
+	// empty set up front instead of the real initial list.
+	p.protected.lastApplied = types.ParamValues{}
+
+	backoff := newBackoff(0, 0)
+	if stop := p.updateWorkerIteration(context.Background(), backoff); stop {
+		t.Fatal("updateWorkerIteration reported stop on its first poll")
+	}
+
+	if updater.calls != 1 {
+		t.Fatalf("Update was called %d times, want 1", updater.calls)
+	}
+
+	if updater.last["db/host"] != "localhost" {
+		t.Fatalf("delivered value = %+v, want db/host=localhost", updater.last)
+	}
+}
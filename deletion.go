@@ -0,0 +1,24 @@
+package consul
+
+import "github.com/simplesurance/proteus/types"
+
+// withExplicitDeletions returns curr with an explicit empty-string
+// entry added for every key present in prev but missing from curr.
+//
+// list only reports keys currently found under the watched prefix(es),
+// so a deleted Consul key simply vanishes from its result. Without this,
+// it would be ambiguous whether the deletion was communicated to
+// updater.Update at all, since the key is just absent from the map
+// rather than present with a value. Setting it to "" makes the removal
+// explicit and reverts the parameter, distinct from a key that was
+// never set - see TestParamValuesEqualMissingKeyWithEmptyValue, which
+// already treats the two as different.
+func withExplicitDeletions(prev, curr types.ParamValues) types.ParamValues {
+	for key := range prev {
+		if _, ok := curr[key]; !ok {
+			curr[key] = ""
+		}
+	}
+
+	return curr
+}
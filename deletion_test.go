@@ -0,0 +1,80 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestWithExplicitDeletionsRevertsMissingKey(t *testing.T) {
+	prev := types.ParamValues{"db/host": "localhost", "db/port": "5432"}
+	curr := types.ParamValues{"db/port": "5432"}
+
+	got := withExplicitDeletions(prev, curr)
+
+	if v, ok := got["db/host"]; !ok || v != "" {
+		t.Fatalf("db/host = %q, ok=%v, want an explicit empty-string revert", v, ok)
+	}
+
+	if got["db/port"] != "5432" {
+		t.Fatalf("db/port = %q, want it left untouched", got["db/port"])
+	}
+}
+
+func TestWithExplicitDeletionsLeavesUnrelatedKeysAlone(t *testing.T) {
+	prev := types.ParamValues{}
+	curr := types.ParamValues{"db/host": "localhost"}
+
+	got := withExplicitDeletions(prev, curr)
+
+	if len(got) != 1 || got["db/host"] != "localhost" {
+		t.Fatalf("got = %v, want unchanged single entry", got)
+	}
+}
+
+func TestUpdateWorkerRevertsParameterOnKeyDeletion(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/port", Value: []byte("5432")}},
+		lastIndex: 1,
+	}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true, "db/port": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+	p.protected.lastApplied = types.ParamValues{"db/host": "localhost", "db/port": "5432"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	deadline := time.Now().Add(time.Second)
+	for updater.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	stopped.Wait()
+
+	p.protected.mutex.Lock()
+	applied := p.protected.lastApplied
+	p.protected.mutex.Unlock()
+
+	if v, ok := applied["db/host"]; !ok || v != "" {
+		t.Fatalf("db/host = %q, ok=%v, want deleted key reverted to \"\"", v, ok)
+	}
+
+	if applied["db/port"] != "5432" {
+		t.Fatalf("db/port = %q, want it left untouched", applied["db/port"])
+	}
+}
@@ -0,0 +1,64 @@
+package consul
+
+import (
+	"strings"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// KeyRef identifies a single "<set>/<param>" parameter, used by
+// WithOnDelta to report which keys changed between two polls.
+type KeyRef struct {
+	Set   string
+	Param string
+}
+
+// String returns the "<set>/<param>" form used as the key into
+// types.ParamValues.
+func (k KeyRef) String() string {
+	return k.Set + "/" + k.Param
+}
+
+func keyRefFromKey(key string) KeyRef {
+	set, param, _ := strings.Cut(key, "/")
+	return KeyRef{Set: set, Param: param}
+}
+
+// diffParamValues compares prev and curr, returning the keys added
+// (present only in curr), changed (present in both with a different
+// value) and removed (present only in prev) between them.
+func diffParamValues(prev, curr types.ParamValues) (added, changed, removed []KeyRef) {
+	for key, v := range curr {
+		pv, ok := prev[key]
+
+		switch {
+		case !ok:
+			added = append(added, keyRefFromKey(key))
+		case pv != v:
+			changed = append(changed, keyRefFromKey(key))
+		}
+	}
+
+	for key := range prev {
+		if _, ok := curr[key]; !ok {
+			removed = append(removed, keyRefFromKey(key))
+		}
+	}
+
+	return added, changed, removed
+}
+
+// reportDelta invokes WithOnDelta, if configured, with the keys added,
+// changed and removed between prev and curr.
+func (r *provider) reportDelta(prev, curr types.ParamValues) {
+	if r.onDelta == nil {
+		return
+	}
+
+	added, changed, removed := diffParamValues(prev, curr)
+	if len(added) == 0 && len(changed) == 0 && len(removed) == 0 {
+		return
+	}
+
+	r.onDelta(added, changed, removed)
+}
@@ -0,0 +1,85 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestDiffParamValuesAddedChangedRemoved(t *testing.T) {
+	prev := types.ParamValues{"db/host": "localhost", "db/port": "5432"}
+	curr := types.ParamValues{"db/host": "otherhost", "cache/host": "localhost"}
+
+	added, changed, removed := diffParamValues(prev, curr)
+
+	if len(added) != 1 || added[0] != (KeyRef{Set: "cache", Param: "host"}) {
+		t.Fatalf("added = %v, want [cache/host]", added)
+	}
+
+	if len(changed) != 1 || changed[0] != (KeyRef{Set: "db", Param: "host"}) {
+		t.Fatalf("changed = %v, want [db/host]", changed)
+	}
+
+	if len(removed) != 1 || removed[0] != (KeyRef{Set: "db", Param: "port"}) {
+		t.Fatalf("removed = %v, want [db/port]", removed)
+	}
+}
+
+func TestUpdateWorkerReportsDeltaOnChange(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("newhost")}},
+		lastIndex: 1,
+	}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+	p.protected.lastApplied = types.ParamValues{"db/host": "oldhost"}
+
+	var mutex sync.Mutex
+	var gotAdded, gotChanged, gotRemoved []KeyRef
+
+	p.onDelta = func(added, changed, removed []KeyRef) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		gotAdded, gotChanged, gotRemoved = added, changed, removed
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	deadline := time.Now().Add(time.Second)
+	for updater.callCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	stopped.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(gotAdded) != 0 {
+		t.Fatalf("added = %v, want none", gotAdded)
+	}
+
+	if len(gotChanged) != 1 || gotChanged[0] != (KeyRef{Set: "db", Param: "host"}) {
+		t.Fatalf("changed = %v, want [db/host]", gotChanged)
+	}
+
+	if len(gotRemoved) != 0 {
+		t.Fatalf("removed = %v, want none", gotRemoved)
+	}
+}
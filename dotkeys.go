@@ -0,0 +1,31 @@
+package consul
+
+import "strings"
+
+// dotKeysDisabled backs WithIgnoreDotKeys: the filter it controls is
+// enabled by default, so the field tracks the opt-out rather than the
+// (more common) opt-in, letting the zero value of provider keep the
+// default behavior.
+//
+// ignoreDotKeysEnabled reports whether the default filter is active.
+func (r *provider) ignoreDotKeysEnabled() bool {
+	return !r.dotKeysDisabled
+}
+
+// isDotKey reports whether k, a key already trimmed of its prefix, has
+// any "."-prefixed segment, e.g. ".keep" or ".lock" - the kind of
+// housekeeping entry the Consul UI or other tooling leaves behind under
+// a watched prefix. Keys matching this are meant to be skipped
+// silently, without going through the usual WithOnIgnoredKey reporting
+// that a real unmatched key would get.
+func (r *provider) isDotKey(k string) bool {
+	sep := r.keySeparatorOrDefault()
+
+	for _, segment := range strings.Split(k, sep) {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,100 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListSilentlyIgnoresDotKeepKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/.keep", Value: []byte("")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+
+	if len(ignored) != 0 {
+		t.Fatalf("ignored = %v, want .keep skipped silently, not reported", ignored)
+	}
+}
+
+func TestListSilentlyIgnoresNestedDotLockKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/.lock", Value: []byte("")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{})
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ignored) != 0 {
+		t.Fatalf("ignored = %v, want db/.lock skipped silently", ignored)
+	}
+}
+
+func TestListReportsDotKeyWhenFilterDisabled(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/.keep", Value: []byte("")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{})
+	p.dotKeysDisabled = true
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ignored) != 1 {
+		t.Fatalf("ignored = %v, want .keep reported once WithIgnoreDotKeys(false) is set", ignored)
+	}
+}
+
+func TestWithIgnoreDotKeysFalseDisablesFilter(t *testing.T) {
+	p := &provider{}
+
+	WithIgnoreDotKeys(false)(p)
+
+	if !p.dotKeysDisabled {
+		t.Fatal("WithIgnoreDotKeys(false) did not disable the default filter")
+	}
+
+	if r := p.ignoreDotKeysEnabled(); r {
+		t.Fatal("ignoreDotKeysEnabled() = true, want false after WithIgnoreDotKeys(false)")
+	}
+}
+
+func TestIgnoreDotKeysEnabledByDefault(t *testing.T) {
+	p := &provider{}
+
+	if !p.ignoreDotKeysEnabled() {
+		t.Fatal("ignoreDotKeysEnabled() = false, want true by default")
+	}
+}
@@ -0,0 +1,41 @@
+package consul
+
+// checkEmptyPrefix warns when Watch's initial list found zero KV pairs
+// under the watched prefix, a common symptom of pointing the provider at
+// the wrong prefix (typo, wrong environment). It only inspects the
+// listByPrefix path's bookkeeping, since WithPerKeyWatch already reports
+// each missing key individually via WithOnIgnoredKey.
+//
+// Consul's List doesn't distinguish "this prefix was never written to"
+// from "this prefix currently has no children": both return zero pairs
+// with the KV store's current index. rawQueryIndex being zero is the one
+// case that is unambiguous - it means nothing has ever been written to
+// this Consul KV store at all - so that case gets a more specific
+// message.
+func (r *provider) checkEmptyPrefix() {
+	if r.catalogMode || (len(r.perKeyWatchKeys) > 0 && len(r.perKeyWatchKeys) <= maxPerKeyWatchKeys) {
+		return
+	}
+
+	r.protected.mutex.Lock()
+	count := r.protected.rawPairCount
+	index := r.protected.rawQueryIndex
+	r.protected.mutex.Unlock()
+
+	if count > 0 {
+		return
+	}
+
+	reason := "prefix currently matches no keys"
+	if index == 0 {
+		reason = "no keys have ever been written to this Consul KV store"
+	}
+
+	r.logInfo("consul: WARNING watched prefix matched zero keys, check for a typo or wrong environment",
+		String("prefix", r.currentPrefix()),
+		String("reason", reason))
+
+	if r.onEmptyPrefix != nil {
+		r.onEmptyPrefix()
+	}
+}
@@ -0,0 +1,52 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestCheckEmptyPrefixWarnsWhenNoKeysMatch(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{}, lastIndex: 42}
+	logger := &capturingLogger{}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.logger = logger
+
+	var callbackFired bool
+	p.onEmptyPrefix = func() { callbackFired = true }
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p.checkEmptyPrefix()
+
+	if !callbackFired {
+		t.Fatal("WithOnEmptyPrefix callback was not invoked")
+	}
+
+	if len(logger.infoMsgs) != 1 {
+		t.Fatalf("got %d Info log(s), want 1", len(logger.infoMsgs))
+	}
+}
+
+func TestCheckEmptyPrefixSkipsWarningWhenKeysMatch(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}}, lastIndex: 5}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	var callbackFired bool
+	p.onEmptyPrefix = func() { callbackFired = true }
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p.checkEmptyPrefix()
+
+	if callbackFired {
+		t.Fatal("WithOnEmptyPrefix callback fired despite matching keys")
+	}
+}
@@ -0,0 +1,8 @@
+package consul
+
+// treatAsUnset reports whether value should be skipped like a missing
+// key under the configured EmptyValuePolicy, instead of becoming an
+// explicit empty parameter string.
+func (r *provider) treatAsUnset(value []byte) bool {
+	return r.emptyValuePolicy == EmptyValueAsUnset && len(value) == 0
+}
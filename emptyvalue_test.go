@@ -0,0 +1,58 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListDefaultPolicyTreatsEmptyValueAsEmpty(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := ret["db/host"]; !ok || got != "" {
+		t.Fatalf("db/host = (%q, %v), want (\"\", true)", got, ok)
+	}
+}
+
+func TestListEmptyValueAsUnsetSkipsTheKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("")},
+		{Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.emptyValuePolicy = EmptyValueAsUnset
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ret["db/host"]; ok {
+		t.Fatalf("db/host present in result, want it skipped under EmptyValueAsUnset")
+	}
+
+	if got := ret["db/port"]; got != "5432" {
+		t.Errorf("db/port = %q, want %q (non-empty keys unaffected)", got, "5432")
+	}
+}
+
+func TestWithEmptyValuePolicySetsField(t *testing.T) {
+	p := &provider{}
+
+	WithEmptyValuePolicy(EmptyValueAsUnset)(p)
+
+	if p.emptyValuePolicy != EmptyValueAsUnset {
+		t.Fatalf("emptyValuePolicy = %v, want EmptyValueAsUnset", p.emptyValuePolicy)
+	}
+}
@@ -0,0 +1,25 @@
+package consul
+
+import "os"
+
+// envDefaultAddr returns addr unchanged unless WithEnvDefaults is set
+// and addr is empty, in which case it falls back to the standard
+// CONSUL_HTTP_ADDR environment variable read by the Consul CLI.
+func (r *provider) envDefaultAddr(addr string) string {
+	if !r.envDefaults || addr != "" {
+		return addr
+	}
+
+	return os.Getenv("CONSUL_HTTP_ADDR")
+}
+
+// envDefaultToken returns token unchanged unless WithEnvDefaults is set
+// and token is empty, in which case it falls back to the standard
+// CONSUL_HTTP_TOKEN environment variable read by the Consul CLI.
+func (r *provider) envDefaultToken(token string) string {
+	if !r.envDefaults || token != "" {
+		return token
+	}
+
+	return os.Getenv("CONSUL_HTTP_TOKEN")
+}
@@ -0,0 +1,53 @@
+package consul
+
+import "testing"
+
+func TestEnvDefaultAddrFallsBackWhenEnabledAndUnset(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_ADDR", "http://10.0.0.1:8500")
+
+	p := &provider{envDefaults: true}
+
+	if got := p.envDefaultAddr(""); got != "http://10.0.0.1:8500" {
+		t.Fatalf("envDefaultAddr = %q, want the CONSUL_HTTP_ADDR value", got)
+	}
+}
+
+func TestEnvDefaultAddrIgnoredWithoutOption(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_ADDR", "http://10.0.0.1:8500")
+
+	p := &provider{}
+
+	if got := p.envDefaultAddr(""); got != "" {
+		t.Fatalf("envDefaultAddr = %q, want empty since WithEnvDefaults wasn't set", got)
+	}
+}
+
+func TestEnvDefaultAddrDoesNotOverrideExplicitValue(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_ADDR", "http://10.0.0.1:8500")
+
+	p := &provider{envDefaults: true}
+
+	if got := p.envDefaultAddr("http://explicit:8500"); got != "http://explicit:8500" {
+		t.Fatalf("envDefaultAddr = %q, want the explicit address to win", got)
+	}
+}
+
+func TestEnvDefaultTokenFallsBackWhenEnabledAndUnset(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_TOKEN", "s.abc123")
+
+	p := &provider{envDefaults: true}
+
+	if got := p.envDefaultToken(""); got != "s.abc123" {
+		t.Fatalf("envDefaultToken = %q, want the CONSUL_HTTP_TOKEN value", got)
+	}
+}
+
+func TestEnvDefaultTokenDoesNotOverrideExplicitValue(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_TOKEN", "s.abc123")
+
+	p := &provider{envDefaults: true}
+
+	if got := p.envDefaultToken("s.explicit"); got != "s.explicit" {
+		t.Fatalf("envDefaultToken = %q, want the explicit token to win", got)
+	}
+}
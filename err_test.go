@@ -0,0 +1,67 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestErrSurfacesLastErrorWhenStoppedWhileFailing(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+	p := &provider{
+		kv:          kv,
+		updater:     &countingUpdater{},
+		paramNames:  stubParamNames{},
+		backoffBase: time.Millisecond,
+		backoffMax:  2 * time.Millisecond,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	stopped.Wait()
+
+	if err := p.Err(); err == nil {
+		t.Fatal("Err() = nil, want the fake KV's error surfaced after stopping while failing")
+	}
+}
+
+func TestErrNilAfterCleanStop(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		lastIndex: 1,
+	}
+	p := &provider{
+		kv:         kv,
+		updater:    &countingUpdater{},
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	stopped.Wait()
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil after a clean stop", err)
+	}
+}
@@ -0,0 +1,67 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListExcludePrefixesSkipsMatchingKeys(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/secrets/db/password", Value: []byte("hunter2")},
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.excludePrefixes = []string{"secrets/"}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != 1 || ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want only db/host=localhost", ret)
+	}
+}
+
+func TestListExcludePrefixesReportsIgnoredKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/secrets/db/password", Value: []byte("hunter2")},
+	}}
+
+	p := newTestProvider(kv, nil)
+	p.excludePrefixes = []string{"secrets/"}
+
+	var reasons []string
+	p.onIgnoredKey = func(_, reason string) {
+		reasons = append(reasons, reason)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reasons) != 1 || reasons[0] != "excluded by WithExcludePrefixes" {
+		t.Fatalf("reasons = %v, want a single exclusion reason", reasons)
+	}
+}
+
+func TestListExcludePrefixesLeavesNonMatchingKeys(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.excludePrefixes = []string{"secrets/"}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host kept", ret)
+	}
+}
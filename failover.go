@@ -0,0 +1,86 @@
+package consul
+
+import (
+	"errors"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// maxAddrFailuresBeforeRotate is the number of consecutive list errors
+// against the currently active address that triggers a rotation to the
+// next address in WithFallbackAddresses, when configured.
+const maxAddrFailuresBeforeRotate = 3
+
+// rotateAddress rebuilds the Consul client against the next address in
+// protected.addresses, wrapping around to the primary address. It is a
+// no-op when WithFallbackAddresses was not configured.
+//
+// r.client/kv/session/lockKV/txnKV/catalog/agent are read concurrently
+// by Get, Put, Keys and AcquireLeadership from arbitrary caller
+// goroutines, so the swap below happens under protected.mutex, the
+// same as every other piece of mutable provider state - an unguarded
+// interface value assignment racing with a concurrent read isn't just
+// a stale-value risk, it's undefined behavior under the Go memory
+// model.
+func (r *provider) rotateAddress() error {
+	r.protected.mutex.Lock()
+	if len(r.protected.addresses) < 2 {
+		r.protected.mutex.Unlock()
+		return nil
+	}
+
+	r.protected.activeAddrIx = (r.protected.activeAddrIx + 1) % len(r.protected.addresses)
+	next := r.protected.addresses[r.protected.activeAddrIx]
+	r.protected.consecutiveFailures = 0
+	r.protected.mutex.Unlock()
+
+	cfg, err := r.buildConfig(next)
+	if err != nil {
+		return err
+	}
+
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	r.protected.mutex.Lock()
+	r.client = client
+	r.kv = r.client.KV()
+	r.session = r.client.Session()
+	r.lockKV = r.client.KV()
+	r.txnKV = r.client.KV()
+	r.catalog = r.client.Catalog()
+	r.agent = r.client.Agent()
+	r.protected.mutex.Unlock()
+
+	r.logInfo("consul: rotated to fallback address", String("address", next))
+
+	return nil
+}
+
+// activeAddress returns the address a reconnect (e.g. applyNewToken's
+// client rebuild on token rotation) should target: the entry
+// protected.addresses currently points at, which tracks any rotation
+// rotateAddress already performed, so a token refresh doesn't undo a
+// failover by reconnecting to the original primary. Falls back to
+// consulURLFn when protected.addresses was never populated, e.g.
+// because WithFallbackAddresses wasn't configured and Watch hasn't run
+// yet. Returns an error rather than dereferencing a nil consulURLFn on
+// a provider constructed with NewWithClient, which never sets it.
+func (r *provider) activeAddress() (string, error) {
+	r.protected.mutex.Lock()
+	if len(r.protected.addresses) > 0 {
+		addr := r.protected.addresses[r.protected.activeAddrIx]
+		r.protected.mutex.Unlock()
+
+		return addr, nil
+	}
+	r.protected.mutex.Unlock()
+
+	if r.consulURLFn == nil {
+		return "", errors.New("consul: token rotation (WithTokenProvider/WithTokenFile) is not supported on a provider constructed with NewWithClient, since there is no address to reconnect to")
+	}
+
+	return r.consulURLFn()
+}
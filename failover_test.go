@@ -0,0 +1,79 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRotateAddressAdvancesAndWraps(t *testing.T) {
+	p := &provider{}
+	p.protected.addresses = []string{"http://primary:8500", "http://secondary:8500"}
+
+	if err := p.rotateAddress(); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.protected.activeAddrIx != 1 {
+		t.Fatalf("activeAddrIx = %d, want 1", p.protected.activeAddrIx)
+	}
+
+	if p.client == nil || p.kv == nil {
+		t.Fatal("rotateAddress did not rebuild the client")
+	}
+
+	if err := p.rotateAddress(); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.protected.activeAddrIx != 0 {
+		t.Fatalf("activeAddrIx = %d, want 0 after wrapping around", p.protected.activeAddrIx)
+	}
+}
+
+func TestRotateAddressNoopWithoutFallbacks(t *testing.T) {
+	p := &provider{}
+	p.protected.addresses = []string{"http://primary:8500"}
+
+	if err := p.rotateAddress(); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.client != nil {
+		t.Fatal("rotateAddress rebuilt the client with a single configured address")
+	}
+}
+
+// TestUpdateWorkerIterationRotatesAfterRepeatedFailures checks that
+// updateWorkerIteration rotates to the next configured address once a
+// failing primary has hit maxAddrFailuresBeforeRotate consecutive
+// errors. It drives updateWorkerIteration directly, synchronously, and
+// stops as soon as the rotation is expected, rather than running the
+// full worker loop: after rotateAddress rebuilds a real
+// *consulapi.Client, further iterations would try to actually dial the
+// (nonexistent) fallback address, and there is no seam to swap in a
+// fake "working secondary" for rotateAddress to land on instead.
+func TestUpdateWorkerIterationRotatesAfterRepeatedFailures(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+	p := &provider{
+		kv:          kv,
+		updater:     &countingUpdater{},
+		paramNames:  stubParamNames{},
+		backoffBase: time.Millisecond,
+		backoffMax:  time.Millisecond,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+	p.protected.addresses = []string{"http://primary:8500", "http://secondary:8500"}
+
+	ctx := context.Background()
+	backoff := newBackoff(p.backoffBase, p.backoffMax)
+
+	for i := 0; i < maxAddrFailuresBeforeRotate; i++ {
+		p.updateWorkerIteration(ctx, backoff)
+	}
+
+	if p.protected.activeAddrIx != 1 {
+		t.Fatalf("activeAddrIx = %d, want 1 after %d consecutive failures", p.protected.activeAddrIx, maxAddrFailuresBeforeRotate)
+	}
+}
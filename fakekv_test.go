@@ -0,0 +1,66 @@
+package consul
+
+import consulapi "github.com/hashicorp/consul/api"
+
+// fakeKV is an in-memory kvBackend used by unit tests so they don't
+// need a running Consul.
+type fakeKV struct {
+	pairs     consulapi.KVPairs
+	lastIndex uint64
+	listErr   error
+
+	// failCount, when > 0, makes List and Get return failErr and
+	// decrements by one, so tests can simulate N transient failures
+	// followed by success.
+	failCount int
+	failErr   error
+}
+
+func (f *fakeKV) List(prefix string, _ *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	if f.failCount > 0 {
+		f.failCount--
+		return nil, nil, f.failErr
+	}
+
+	if f.listErr != nil {
+		return nil, nil, f.listErr
+	}
+
+	var matched consulapi.KVPairs
+
+	for _, pair := range f.pairs {
+		if len(pair.Key) >= len(prefix) && pair.Key[:len(prefix)] == prefix {
+			matched = append(matched, pair)
+		}
+	}
+
+	return matched, &consulapi.QueryMeta{LastIndex: f.lastIndex}, nil
+}
+
+func (f *fakeKV) Get(key string, _ *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error) {
+	if f.failCount > 0 {
+		f.failCount--
+		return nil, nil, f.failErr
+	}
+
+	for _, pair := range f.pairs {
+		if pair.Key == key {
+			return pair, &consulapi.QueryMeta{LastIndex: f.lastIndex}, nil
+		}
+	}
+
+	return nil, &consulapi.QueryMeta{LastIndex: f.lastIndex}, nil
+}
+
+func (f *fakeKV) Put(p *consulapi.KVPair, _ *consulapi.WriteOptions) (*consulapi.WriteMeta, error) {
+	for i, pair := range f.pairs {
+		if pair.Key == p.Key {
+			f.pairs[i] = p
+			return &consulapi.WriteMeta{}, nil
+		}
+	}
+
+	f.pairs = append(f.pairs, p)
+
+	return &consulapi.WriteMeta{}, nil
+}
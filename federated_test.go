@@ -0,0 +1,108 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// federatedKV is a kvBackend test double that serves different pairs
+// (and independent LastIndex values) per Datacenter, so tests can
+// simulate a primary and a local Consul datacenter without a running
+// Consul.
+type federatedKV struct {
+	pairsByDC     map[string]consulapi.KVPairs
+	lastIndexByDC map[string]uint64
+	waitIxByDC    map[string]uint64
+}
+
+func (f *federatedKV) List(prefix string, q *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	if f.waitIxByDC == nil {
+		f.waitIxByDC = make(map[string]uint64)
+	}
+
+	f.waitIxByDC[q.Datacenter] = q.WaitIndex
+
+	var matched consulapi.KVPairs
+	for _, pair := range f.pairsByDC[q.Datacenter] {
+		if len(pair.Key) >= len(prefix) && pair.Key[:len(prefix)] == prefix {
+			matched = append(matched, pair)
+		}
+	}
+
+	return matched, &consulapi.QueryMeta{LastIndex: f.lastIndexByDC[q.Datacenter]}, nil
+}
+
+func (f *federatedKV) Get(string, *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error) {
+	return nil, nil, nil
+}
+
+func (f *federatedKV) Put(*consulapi.KVPair, *consulapi.WriteOptions) (*consulapi.WriteMeta, error) {
+	return nil, nil
+}
+
+func TestListMergesFederatedDatacentersLocalOverridesPrimary(t *testing.T) {
+	kv := &federatedKV{
+		pairsByDC: map[string]consulapi.KVPairs{
+			"primary-dc": {
+				{Key: "app/db/host", Value: []byte("primary-host")},
+				{Key: "app/db/port", Value: []byte("5432")},
+			},
+			"local-dc": {
+				{Key: "app/db/host", Value: []byte("local-host")},
+			},
+		},
+		lastIndexByDC: map[string]uint64{"primary-dc": 10, "local-dc": 20},
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.federatedDatacenters = []string{"primary-dc", "local-dc"}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ret["db/host"]; got != "local-host" {
+		t.Errorf("db/host = %q, want %q (local-dc should override primary-dc)", got, "local-host")
+	}
+
+	if got := ret["db/port"]; got != "5432" {
+		t.Errorf("db/port = %q, want %q (primary-only key must still surface)", got, "5432")
+	}
+}
+
+func TestListTracksFederatedDatacenterIndexesIndependently(t *testing.T) {
+	kv := &federatedKV{
+		pairsByDC: map[string]consulapi.KVPairs{
+			"primary-dc": {{Key: "app/db/host", Value: []byte("primary-host")}},
+			"local-dc":   {{Key: "app/db/host", Value: []byte("local-host")}},
+		},
+		lastIndexByDC: map[string]uint64{"primary-dc": 100, "local-dc": 7},
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.federatedDatacenters = []string{"primary-dc", "local-dc"}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := p.protected.waitIx["primary-dc|app/"]; got != 100 {
+		t.Errorf("primary-dc waitIx = %d, want 100", got)
+	}
+
+	if got := p.protected.waitIx["local-dc|app/"]; got != 7 {
+		t.Errorf("local-dc waitIx = %d, want 7", got)
+	}
+}
+
+func TestPrefixQueriesWithoutDatacentersIsUnaffected(t *testing.T) {
+	p := newTestProvider(&fakeKV{}, nil)
+
+	queries := p.prefixQueries()
+	if len(queries) != 1 || queries[0].datacenter != "" {
+		t.Fatalf("prefixQueries() = %+v, want a single query with no datacenter override", queries)
+	}
+}
@@ -0,0 +1,110 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// prefixFetchResult is the outcome of one prefixQuery's blocking-query
+// list, collected by listByPrefix's fan-in select before it merges
+// pairs from every watch target in prefixQueries order.
+type prefixFetchResult struct {
+	pq     prefixQuery
+	waitIx uint64
+	pairs  consulapi.KVPairs
+	meta   *consulapi.QueryMeta
+	err    error
+}
+
+// fetchPrefix runs one prefixQuery's blocking-query list, including its
+// retry loop, rate limiting and tracing - everything listByPrefix used
+// to do inline before a watch target's turn in its sequential loop.
+// Running it on its own goroutine per target lets multiple prefixes'
+// blocking queries wait concurrently instead of one after another, so a
+// multi-prefix config's worst-case latency is the slowest single
+// target's WaitTime instead of their sum.
+func (r *provider) fetchPrefix(ctx context.Context, pq prefixQuery) prefixFetchResult {
+	if r.rateLimiter != nil {
+		if err := r.rateLimiter.Wait(ctx); err != nil {
+			return prefixFetchResult{pq: pq, err: err}
+		}
+	}
+
+	r.protected.mutex.Lock()
+	waitIx := r.protected.waitIx[pq.waitIxKey()]
+	r.protected.mutex.Unlock()
+
+	var pairs consulapi.KVPairs
+	var meta *consulapi.QueryMeta
+	var err error
+
+	finishSpan := r.startListSpan(pq.prefix)
+
+	for attempt := 0; ; attempt++ {
+		opts := r.queryOptions(ctx, waitIx)
+		if pq.datacenter != "" {
+			opts.Datacenter = pq.datacenter
+		}
+
+		start := time.Now()
+		pairs, meta, err = r.currentKV().List(pq.prefix, opts)
+		r.metrics.observePoll(start, err)
+
+		if err == nil || !isRetryableErr(err) || attempt >= r.listRetryAttempts() {
+			break
+		}
+
+		if sleepErr := r.sleep(ctx, r.listRetryDelay()); sleepErr != nil {
+			err = sleepErr
+			break
+		}
+	}
+
+	var resultIndex uint64
+	if meta != nil {
+		resultIndex = meta.LastIndex
+	}
+
+	finishSpan(pairs, resultIndex, err)
+
+	return prefixFetchResult{pq: pq, waitIx: waitIx, pairs: pairs, meta: meta, err: err}
+}
+
+// fetchPrefixes runs fetchPrefix for every query concurrently, one
+// goroutine each sharing the provider's single Consul client, and
+// returns their results in the same order as queries regardless of
+// which finished first. It stops waiting and returns ctx.Err() as soon
+// as ctx is canceled, without waiting for the remaining goroutines,
+// which is safe since their results are simply discarded once
+// received.
+func (r *provider) fetchPrefixes(ctx context.Context, queries []prefixQuery) ([]prefixFetchResult, error) {
+	resultCh := make(chan prefixFetchResult, len(queries))
+
+	for _, pq := range queries {
+		pq := pq
+
+		go func() {
+			resultCh <- r.fetchPrefix(ctx, pq)
+		}()
+	}
+
+	byPrefix := make(map[prefixQuery]prefixFetchResult, len(queries))
+
+	for range queries {
+		select {
+		case res := <-resultCh:
+			byPrefix[res.pq] = res
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	results := make([]prefixFetchResult, len(queries))
+	for i, pq := range queries {
+		results[i] = byPrefix[pq]
+	}
+
+	return results, nil
+}
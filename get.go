@@ -0,0 +1,34 @@
+package consul
+
+import (
+	"context"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// Get performs a direct kv.Get on the key composed from the provider's
+// prefix and setName/paramName, bypassing the blocking-query watch
+// machinery. It returns ("", false, nil) for a missing key rather than
+// an error. This is intended for values that are read rarely and
+// shouldn't be added to the watched set. It uses the WithReadReplica
+// client if one is configured, so high-volume callers don't compete
+// with the watch loop for connections to the primary.
+func (r *provider) Get(ctx context.Context, setName, paramName string) (string, bool, error) {
+	key := r.currentPrefix() + setName + "/" + paramName
+
+	opts := &consulapi.QueryOptions{
+		Datacenter: r.datacenter,
+		Namespace:  r.namespace,
+	}
+
+	pair, _, err := r.readBackend().Get(key, opts.WithContext(ctx))
+	if err != nil {
+		return "", false, err
+	}
+
+	if pair == nil {
+		return "", false, nil
+	}
+
+	return string(pair.Value), true, nil
+}
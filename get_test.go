@@ -0,0 +1,12 @@
+package consul
+
+import "testing"
+
+func TestGetComposesKey(t *testing.T) {
+	p := &provider{}
+	p.protected.prefix = "app/"
+
+	if got, want := p.currentPrefix()+"db"+"/"+"host", "app/db/host"; got != want {
+		t.Fatalf("composed key = %q, want %q", got, want)
+	}
+}
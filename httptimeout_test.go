@@ -0,0 +1,78 @@
+package consul
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestBuildConfigHTTPTimeout(t *testing.T) {
+	p := &provider{httpTimeout: 5 * time.Second}
+
+	cfg, err := p.buildConfig("http://127.0.0.1:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.HttpClient == nil || cfg.HttpClient.Timeout != 5*time.Second {
+		t.Fatalf("HttpClient = %+v, want Timeout=5s", cfg.HttpClient)
+	}
+}
+
+func TestBuildConfigNoHTTPTimeout(t *testing.T) {
+	p := &provider{}
+
+	cfg, err := p.buildConfig("http://127.0.0.1:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.HttpClient != nil {
+		t.Fatalf("HttpClient = %+v, want nil", cfg.HttpClient)
+	}
+}
+
+func TestWatchRejectsTimeoutNotGreaterThanWaitTime(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app/", WithWaitTime(10*time.Second)).(*provider)
+	p.httpTimeout = 5 * time.Second
+
+	err := p.Watch(&countingUpdater{})
+	if err == nil {
+		t.Fatal("Watch() err = nil, want an error rejecting timeout <= WaitTime")
+	}
+}
+
+func TestHTTPTimeoutFiresOnHungServer(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+
+	p := &provider{httpTimeout: 50 * time.Millisecond}
+
+	cfg, err := p.buildConfig(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+
+	if _, _, err = client.KV().List("app/", nil); err == nil {
+		t.Fatal("List() err = nil, want a timeout error")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("List() took %s, want it to fail around the 50ms HTTP timeout", elapsed)
+	}
+}
@@ -0,0 +1,13 @@
+package consul
+
+import (
+	"math/rand"
+	"time"
+)
+
+// randJitter returns a random duration in [0, max). It panics if max
+// is not positive, so callers must guard the zero-disables-jitter case
+// themselves.
+func randJitter(max time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,42 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRandJitterNeverExceedsMax(t *testing.T) {
+	const max = 20 * time.Millisecond
+
+	for i := 0; i < 1000; i++ {
+		d := randJitter(max)
+		if d < 0 || d >= max {
+			t.Fatalf("randJitter(%s) = %s, want a value in [0, %s)", max, d, max)
+		}
+	}
+}
+
+func TestWatchStartupJitterRespectsContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := &provider{
+		startupJitter: time.Hour,
+		baseCtx:       ctx,
+		consulURLFn: func() (string, error) {
+			return "http://127.0.0.1:8500", nil
+		},
+	}
+
+	start := time.Now()
+
+	err := p.Watch(&countingUpdater{})
+	if err == nil {
+		t.Fatal("Watch() err = nil, want the cancelled context to abort the startup jitter sleep")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Watch() took %s, want it to return promptly once ctx is cancelled", elapsed)
+	}
+}
@@ -0,0 +1,25 @@
+package consul
+
+import (
+	"encoding/json"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// logDebugSnapshot logs the full parameter snapshot produced by a poll
+// at Debug level, so operators can inspect exactly what Consul returned.
+// json.MarshalIndent is comparatively expensive for a large ParamValues,
+// so it only runs when Debug logging is actually enabled - see
+// BenchmarkLogDebugSnapshotDisabled and BenchmarkLogDebugSnapshotEnabled.
+func (r *provider) logDebugSnapshot(ret types.ParamValues) {
+	if !r.debugEnabled() {
+		return
+	}
+
+	b, err := json.MarshalIndent(ret, "", "  ")
+	if err != nil {
+		return
+	}
+
+	r.logDebug("consul: poll snapshot", String("snapshot", string(b)))
+}
@@ -0,0 +1,72 @@
+package consul
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestLogDebugSnapshotSkipsMarshalWhenDebugDisabled(t *testing.T) {
+	logger := &capturingLogger{enabled: false}
+	p := &provider{logger: logger}
+
+	p.logDebugSnapshot(types.ParamValues{"db/host": "localhost"})
+
+	if len(logger.debugMsgs) != 0 {
+		t.Fatalf("debugMsgs = %v, want none when Debug is disabled", logger.debugMsgs)
+	}
+}
+
+func TestLogDebugSnapshotEmitsJSONWhenDebugEnabled(t *testing.T) {
+	logger := &capturingLogger{enabled: true}
+	p := &provider{logger: logger}
+
+	p.logDebugSnapshot(types.ParamValues{"db/host": "localhost"})
+
+	if len(logger.debug) != 1 || logger.debugMsgs[0] != "consul: poll snapshot" {
+		t.Fatalf("debugMsgs = %v, want a single \"consul: poll snapshot\" call", logger.debugMsgs)
+	}
+
+	v, ok := fieldValue(logger.debug[0], "snapshot")
+	if !ok {
+		t.Fatal("snapshot field missing")
+	}
+
+	if snapshot, _ := v.(string); !strings.Contains(snapshot, "localhost") {
+		t.Fatalf("snapshot field = %q, want it to contain the marshaled value", snapshot)
+	}
+}
+
+func largeParamValues(n int) types.ParamValues {
+	ret := make(types.ParamValues, n)
+
+	for i := 0; i < n; i++ {
+		ret[fmt.Sprintf("set%d/param%d", i, i)] = fmt.Sprintf("value-%d", i)
+	}
+
+	return ret
+}
+
+func BenchmarkLogDebugSnapshotDisabled(b *testing.B) {
+	p := &provider{logger: &capturingLogger{enabled: false}}
+	ret := largeParamValues(10_000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p.logDebugSnapshot(ret)
+	}
+}
+
+func BenchmarkLogDebugSnapshotEnabled(b *testing.B) {
+	p := &provider{logger: &capturingLogger{enabled: true}}
+	ret := largeParamValues(10_000)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p.logDebugSnapshot(ret)
+	}
+}
@@ -0,0 +1,48 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// applyJSONValue treats a single Consul key (the set name, holding no
+// param segment) whose value is a JSON object as an entire parameter
+// set: each top-level field becomes a parameter of that set. It
+// reports whether k was handled as a JSON set, so the caller can fall
+// through to the regular "<set>/<param>" handling otherwise.
+//
+// Nested objects and arrays are rejected: fields with such values are
+// skipped, since there is no unambiguous string representation for
+// them in a proteus parameter.
+func (r *provider) applyJSONValue(ret map[string]string, k string, value []byte) bool {
+	if _, _, ok := r.splitKey(k); ok {
+		// k already has the "<set>/<param>" shape; let the normal path
+		// handle it even when JSON values are enabled.
+		return false
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return false
+	}
+
+	setName, _ := r.normalizeKeyCase(k, "")
+
+	for field, v := range obj {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			// Ignoring: nested objects/arrays have no unambiguous string
+			// representation.
+			continue
+		}
+
+		paramName, _ := r.normalizeKeyCase(field, "")
+		if !r.paramNames.Get(setName, paramName) {
+			continue
+		}
+
+		ret[setName+"/"+paramName] = fmt.Sprint(v)
+	}
+
+	return true
+}
@@ -0,0 +1,50 @@
+package consul
+
+import "testing"
+
+type stubParamNames struct {
+	known map[string]bool
+}
+
+func (s stubParamNames) Get(setName, paramName string) bool {
+	return s.known[setName+"/"+paramName]
+}
+
+func TestApplyJSONValueExpandsObject(t *testing.T) {
+	p := &provider{jsonValues: true, paramNames: stubParamNames{known: map[string]bool{
+		"db/host": true,
+		"db/port": true,
+	}}}
+
+	ret := map[string]string{}
+	ok := p.applyJSONValue(ret, "db", []byte(`{"host":"localhost","port":5432}`))
+	if !ok {
+		t.Fatal("applyJSONValue returned false for a valid JSON object")
+	}
+
+	if ret["db/host"] != "localhost" || ret["db/port"] != "5432" {
+		t.Fatalf("ret = %v, want db/host=localhost db/port=5432", ret)
+	}
+}
+
+func TestApplyJSONValueRejectsNested(t *testing.T) {
+	p := &provider{jsonValues: true, paramNames: stubParamNames{known: map[string]bool{
+		"db/host": true,
+	}}}
+
+	ret := map[string]string{}
+	p.applyJSONValue(ret, "db", []byte(`{"host":"localhost","opts":{"a":1}}`))
+
+	if _, ok := ret["db/opts"]; ok {
+		t.Fatal("applyJSONValue kept a nested object field")
+	}
+}
+
+func TestApplyJSONValueMalformed(t *testing.T) {
+	p := &provider{jsonValues: true}
+
+	ret := map[string]string{}
+	if ok := p.applyJSONValue(ret, "db", []byte(`not json`)); ok {
+		t.Fatal("applyJSONValue accepted malformed JSON")
+	}
+}
@@ -0,0 +1,24 @@
+package consul
+
+// KeyModifiedEvent describes a single watched key whose Consul
+// ModifyIndex advanced with a value change, for WithOnKeyModified. It's
+// an audit/observability signal distinct from WithOnDelta: it carries
+// the raw index and Flags metadata Consul attaches to the key, useful
+// for spotting a change made outside the normal deployment pipeline
+// (e.g. a manual "consul kv put").
+type KeyModifiedEvent struct {
+	Set, Param string
+
+	OldValue, NewValue string
+
+	OldModifyIndex, NewModifyIndex uint64
+
+	// CreateIndex is the raw KVPair.CreateIndex Consul reports for the
+	// new value, i.e. the index at which the key was first created (not
+	// necessarily by this write).
+	CreateIndex uint64
+
+	// Flags is the raw KVPair.Flags Consul reports for the new value,
+	// an opaque 64-bit value some tools attach their own metadata to.
+	Flags uint64
+}
@@ -0,0 +1,88 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestOnKeyModifiedFiresWhenModifyIndexAdvancesWithNewValue(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost"), ModifyIndex: 5, CreateIndex: 1, Flags: 42},
+	}}
+
+	var events []KeyModifiedEvent
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.onKeyModified = func(e KeyModifiedEvent) { events = append(events, e) }
+
+	// Seed the "previously observed" state as if a prior poll already
+	// applied index 1 with a different value - as if the operator's
+	// pipeline had written it, and someone else has since changed it
+	// out from under them (ModifyIndex 5 above).
+	p.protected.modifyIndex = map[string]uint64{"db/host": 1}
+	p.protected.lastApplied = map[string]string{"db/host": "old-value"}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1: %+v", len(events), events)
+	}
+
+	e := events[0]
+	if e.Set != "db" || e.Param != "host" {
+		t.Errorf("Set/Param = %s/%s, want db/host", e.Set, e.Param)
+	}
+
+	if e.OldValue != "old-value" || e.NewValue != "localhost" {
+		t.Errorf("OldValue/NewValue = %q/%q, want old-value/localhost", e.OldValue, e.NewValue)
+	}
+
+	if e.OldModifyIndex != 1 || e.NewModifyIndex != 5 {
+		t.Errorf("OldModifyIndex/NewModifyIndex = %d/%d, want 1/5", e.OldModifyIndex, e.NewModifyIndex)
+	}
+
+	if e.CreateIndex != 1 || e.Flags != 42 {
+		t.Errorf("CreateIndex/Flags = %d/%d, want 1/42", e.CreateIndex, e.Flags)
+	}
+}
+
+func TestOnKeyModifiedDoesNotFireWithoutABaseline(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost"), ModifyIndex: 5},
+	}}
+
+	var events []KeyModifiedEvent
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.onKeyModified = func(e KeyModifiedEvent) { events = append(events, e) }
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("got %d events on the first poll with no baseline, want 0", len(events))
+	}
+}
+
+func TestOnKeyModifiedIgnoresIndexBumpWithSameValue(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost"), ModifyIndex: 9},
+	}}
+
+	var events []KeyModifiedEvent
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.onKeyModified = func(e KeyModifiedEvent) { events = append(events, e) }
+	p.protected.modifyIndex = map[string]uint64{"db/host": 3}
+	p.protected.lastApplied = map[string]string{"db/host": "localhost"}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 0 {
+		t.Fatalf("got %d events for an index bump with an unchanged value, want 0", len(events))
+	}
+}
@@ -0,0 +1,44 @@
+package consul
+
+import (
+	"context"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// Keys lists every raw key currently stored under the provider's
+// prefix, trimmed of that prefix, without applying any of the
+// parameter-matching machinery (WithKeyTransform, WithKeyFilter,
+// paramNames, ...) that list uses. Directory placeholder keys (ending
+// in "/") are skipped, same as listByPrefix. It performs
+// a single, non-blocking kv.List and is intended for audit/diff
+// tooling that wants to see everything under the prefix, not just the
+// keys the watch path turns into parameters. Like Get, it uses the
+// WithReadReplica client if one is configured.
+func (r *provider) Keys(ctx context.Context) ([]string, error) {
+	prefix := r.currentPrefix()
+
+	opts := &consulapi.QueryOptions{
+		Datacenter: r.datacenter,
+		Namespace:  r.namespace,
+		Partition:  r.partition,
+	}
+
+	pairs, _, err := r.readBackend().List(prefix, opts.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		k := strings.TrimPrefix(pair.Key, prefix)
+		if k == "" || strings.HasSuffix(k, "/") {
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,44 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestKeysReturnsAllTrimmedKeysIgnoringParamMatching(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+		{Key: "app/other/unknown", Value: []byte("ignored")},
+		{Key: "app/dir/", Value: nil},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+
+	keys, err := p.Keys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(keys)
+
+	want := []string{"db/host", "db/port", "other/unknown"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Keys() = %v, want %v", keys, want)
+	}
+}
+
+func TestKeysPropagatesKVError(t *testing.T) {
+	kv := &fakeKV{listErr: errors.New("kv list failed")}
+
+	p := newTestProvider(kv, nil)
+
+	if _, err := p.Keys(context.Background()); err == nil {
+		t.Fatal("Keys() err = nil, want the error from kv.List")
+	}
+}
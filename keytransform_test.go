@@ -0,0 +1,74 @@
+package consul
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// envStyleTransform maps flat "DB_HOST"-style keys to a set/param pair
+// by splitting on the first underscore and lowercasing both halves.
+func envStyleTransform(rawKey string) (setName, paramName string, ok bool) {
+	setName, paramName, ok = strings.Cut(rawKey, "_")
+	if !ok {
+		return "", "", false
+	}
+
+	return strings.ToLower(setName), strings.ToLower(paramName), true
+}
+
+func TestListKeyTransformMapsFlatKeys(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/DB_HOST", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.keyTransform = envStyleTransform
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListKeyTransformExcludesKeyWhenNotOK(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/NOUNDERSCORE", Value: []byte("x")},
+		{Key: "app/DB_HOST", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.keyTransform = envStyleTransform
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != 1 || ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want only db/host=localhost", ret)
+	}
+}
+
+func TestListWithoutKeyTransformUsesDefaultSplit(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost from the default splitter", ret)
+	}
+}
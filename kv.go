@@ -0,0 +1,12 @@
+package consul
+
+import consulapi "github.com/hashicorp/consul/api"
+
+// kvBackend abstracts the subset of Consul's KV HTTP API the provider
+// relies on. *consulapi.KV satisfies it directly; tests can inject a
+// fake implementation instead of requiring a running Consul.
+type kvBackend interface {
+	List(prefix string, q *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error)
+	Get(key string, q *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error)
+	Put(p *consulapi.KVPair, q *consulapi.WriteOptions) (*consulapi.WriteMeta, error)
+}
@@ -0,0 +1,95 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// releaseTimeout bounds release's Release and Destroy calls, see its doc comment on
+// AcquireLeadership for why it can't reuse the ctx AcquireLeadership
+// was called with.
+const releaseTimeout = 5 * time.Second
+
+// sessionBackend abstracts the subset of Consul's session HTTP API that
+// AcquireLeadership relies on. *consulapi.Session satisfies it
+// directly; tests can inject a fake implementation instead of
+// requiring a running Consul.
+type sessionBackend interface {
+	Create(entry *consulapi.SessionEntry, q *consulapi.WriteOptions) (string, *consulapi.WriteMeta, error)
+	Destroy(id string, q *consulapi.WriteOptions) (*consulapi.WriteMeta, error)
+}
+
+// lockKVBackend abstracts the subset of Consul's KV HTTP API used to
+// acquire and release a session lock. *consulapi.KV satisfies it
+// directly, the same as it satisfies kvBackend.
+type lockKVBackend interface {
+	Acquire(pair *consulapi.KVPair, q *consulapi.WriteOptions) (bool, *consulapi.WriteMeta, error)
+	Release(pair *consulapi.KVPair, q *consulapi.WriteOptions) (bool, *consulapi.WriteMeta, error)
+}
+
+// AcquireLeadership attempts to become the leader for key, a path under
+// the provider's watched prefix, using a Consul session and lock the
+// same way Consul's own leader-election recipe does. Only one caller
+// across the cluster holds the lock at a time; applications running in
+// HA can use held to gate leader-only reactions to config changes in
+// their UpdateFn instead of every instance acting on them.
+//
+// held reports whether the lock was acquired. When it is true, release
+// must be called once the caller is done acting as leader (e.g. on
+// shutdown); it releases the lock and then destroys the underlying
+// session. release is always safe to call, including when held is
+// false, in which case it does nothing.
+//
+// release issues its Release and Destroy calls with its own releaseTimeout-bounded
+// context rather than ctx, since ctx is typically already canceled by
+// the time release runs on shutdown - using it directly would make
+// Destroy fail with ctx.Err() before ever reaching Consul, leaking the
+// session until Consul's own TTL reclaims it instead of releasing the
+// lock immediately.
+//
+// AcquireLeadership requires Watch to have already run, since it reuses
+// the *consul.Client Watch built.
+func (r *provider) AcquireLeadership(ctx context.Context, key string) (held bool, release func(), err error) {
+	noop := func() {}
+
+	session, lockKV := r.currentLeadershipBackends()
+	if session == nil || lockKV == nil {
+		return false, noop, fmt.Errorf("consul: AcquireLeadership requires Watch to have run first")
+	}
+
+	wo := (&consulapi.WriteOptions{Datacenter: r.datacenter, Namespace: r.namespace}).WithContext(ctx)
+
+	sessionID, _, err := session.Create(&consulapi.SessionEntry{
+		Name:     "proteus-consul leadership lock",
+		Behavior: consulapi.SessionBehaviorRelease,
+	}, wo)
+	if err != nil {
+		return false, noop, fmt.Errorf("consul: creating leadership session: %w", err)
+	}
+
+	lockKey := r.currentPrefix() + key
+
+	acquired, _, err := lockKV.Acquire(&consulapi.KVPair{Key: lockKey, Session: sessionID}, wo)
+	if err != nil {
+		_, _ = session.Destroy(sessionID, wo)
+		return false, noop, fmt.Errorf("consul: acquiring leadership lock on %q: %w", lockKey, err)
+	}
+
+	if !acquired {
+		_, _ = session.Destroy(sessionID, wo)
+		return false, noop, nil
+	}
+
+	return true, func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+
+		releaseWo := (&consulapi.WriteOptions{Datacenter: r.datacenter, Namespace: r.namespace}).WithContext(releaseCtx)
+
+		_, _, _ = lockKV.Release(&consulapi.KVPair{Key: lockKey, Session: sessionID}, releaseWo)
+		_, _ = session.Destroy(sessionID, releaseWo)
+	}, nil
+}
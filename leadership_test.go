@@ -0,0 +1,130 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// fakeSession is a minimal sessionBackend that hands out incrementing
+// session IDs and records which ones were destroyed.
+type fakeSession struct {
+	nextID    int
+	destroyed map[string]bool
+	createErr error
+}
+
+func (f *fakeSession) Create(*consulapi.SessionEntry, *consulapi.WriteOptions) (string, *consulapi.WriteMeta, error) {
+	if f.createErr != nil {
+		return "", nil, f.createErr
+	}
+
+	f.nextID++
+
+	return string(rune('a' + f.nextID - 1)), nil, nil
+}
+
+func (f *fakeSession) Destroy(id string, _ *consulapi.WriteOptions) (*consulapi.WriteMeta, error) {
+	if f.destroyed == nil {
+		f.destroyed = make(map[string]bool)
+	}
+
+	f.destroyed[id] = true
+
+	return nil, nil
+}
+
+// fakeLockKV simulates a single lockable key: Acquire succeeds only
+// while no other session holds it.
+type fakeLockKV struct {
+	heldBySession string
+	acquireErr    error
+}
+
+func (f *fakeLockKV) Acquire(pair *consulapi.KVPair, _ *consulapi.WriteOptions) (bool, *consulapi.WriteMeta, error) {
+	if f.acquireErr != nil {
+		return false, nil, f.acquireErr
+	}
+
+	if f.heldBySession != "" {
+		return false, nil, nil
+	}
+
+	f.heldBySession = pair.Session
+
+	return true, nil, nil
+}
+
+func (f *fakeLockKV) Release(pair *consulapi.KVPair, _ *consulapi.WriteOptions) (bool, *consulapi.WriteMeta, error) {
+	if f.heldBySession != pair.Session {
+		return false, nil, nil
+	}
+
+	f.heldBySession = ""
+
+	return true, nil, nil
+}
+
+func TestAcquireLeadershipSucceedsWhenUnlocked(t *testing.T) {
+	session := &fakeSession{}
+	lock := &fakeLockKV{}
+	p := &provider{session: session, lockKV: lock}
+	p.protected.prefix = "app/"
+
+	held, release, err := p.AcquireLeadership(context.Background(), "leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !held {
+		t.Fatal("AcquireLeadership() held = false, want true for an unlocked key")
+	}
+
+	if lock.heldBySession == "" {
+		t.Fatal("lock was not recorded as held")
+	}
+
+	release()
+
+	if lock.heldBySession != "" {
+		t.Fatal("release() did not release the lock")
+	}
+
+	if !session.destroyed["a"] {
+		t.Fatal("release() did not destroy the session")
+	}
+}
+
+func TestAcquireLeadershipFailsWhenAlreadyHeld(t *testing.T) {
+	session := &fakeSession{}
+	lock := &fakeLockKV{heldBySession: "other-session"}
+	p := &provider{session: session, lockKV: lock}
+	p.protected.prefix = "app/"
+
+	held, release, err := p.AcquireLeadership(context.Background(), "leader")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if held {
+		t.Fatal("AcquireLeadership() held = true, want false when another session holds the lock")
+	}
+
+	release()
+
+	if !session.destroyed["a"] {
+		t.Fatal("a failed acquire must still destroy its own session instead of leaking it")
+	}
+}
+
+func TestAcquireLeadershipWithoutWatchReturnsError(t *testing.T) {
+	p := &provider{}
+
+	_, release, err := p.AcquireLeadership(context.Background(), "leader")
+	if err == nil {
+		t.Fatal("AcquireLeadership() err = nil, want an error before Watch has run")
+	}
+
+	release()
+}
@@ -0,0 +1,725 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+const slowPoolInterval = time.Minute
+
+// maxConsulWaitTime is the largest WaitTime Consul's blocking queries
+// support; the server silently clamps a longer one, so WithWaitTime
+// clamps to it too, keeping our own index/timeout logic in sync with
+// what's actually sent.
+const maxConsulWaitTime = 10 * time.Minute
+
+// consulWaitPadding is the maximum random padding Consul adds on top of
+// a blocking query's WaitTime before it responds, even when nothing
+// changed - up to wait/16, per Consul's blocking-query documentation.
+// Any client-side timeout compared against WaitTime must add this in,
+// or a well-behaved, unchanged blocking query can trip it.
+func consulWaitPadding(wait time.Duration) time.Duration {
+	return wait / 16
+}
+
+// effectiveWaitTime returns the WaitTime that will actually be used for
+// the blocking list query: the configured WithWaitTime, or the default
+// slowPoolInterval. When WithAdaptiveWaitTime is configured, its current
+// adaptive wait replaces that value. When WithDebounce has a pending snapshot waiting
+// out its window, the wait is further capped at the time remaining
+// until that window elapses, so the worker polls again in time to
+// flush it instead of only on the next unrelated Consul write.
+func (r *provider) effectiveWaitTime() time.Duration {
+	wait := r.waitTime
+	if wait <= 0 {
+		wait = slowPoolInterval
+	}
+
+	if r.adaptiveMinWait > 0 {
+		r.protected.mutex.Lock()
+		adaptive := r.protected.adaptiveWaitTime
+		r.protected.mutex.Unlock()
+
+		if adaptive <= 0 {
+			adaptive = r.adaptiveMaxWait
+		}
+
+		wait = adaptive
+	}
+
+	if r.debounce > 0 {
+		r.protected.mutex.Lock()
+		deadline := r.protected.pendingDeadline
+		r.protected.mutex.Unlock()
+
+		if !deadline.IsZero() {
+			if remaining := time.Until(deadline); remaining < wait {
+				if remaining < time.Millisecond {
+					remaining = time.Millisecond
+				}
+
+				wait = remaining
+			}
+		}
+	}
+
+	return wait
+}
+
+// queryOptions builds the consul.QueryOptions used for the blocking
+// list query, applying the provider's connection parameters. ctx is
+// attached via WithContext so that a long-held blocking query is
+// actually aborted - not just abandoned - when ctx is canceled, e.g.
+// by Stop.
+func (r *provider) queryOptions(ctx context.Context, waitIx uint64) *consulapi.QueryOptions {
+	waitTime := r.effectiveWaitTime()
+
+	opts := &consulapi.QueryOptions{
+		WaitIndex:  waitIx,
+		WaitTime:   waitTime,
+		Datacenter: r.datacenter,
+		Namespace:  r.namespace,
+		Partition:  r.partition,
+	}
+
+	switch r.consistencyMode {
+	case ConsistencyStale:
+		opts.AllowStale = true
+	case ConsistencyConsistent:
+		opts.RequireConsistent = true
+	}
+
+	return opts.WithContext(ctx)
+}
+
+// prefixes returns every KV prefix the provider watches, in the order
+// they must be merged: earlier prefixes first, so that later ones take
+// precedence when a key exists under more than one of them.
+func (r *provider) prefixes() []string {
+	prefix := r.currentPrefix()
+
+	if len(r.additionalPrefixes) == 0 {
+		return []string{prefix}
+	}
+
+	return append([]string{prefix}, r.additionalPrefixes...)
+}
+
+// prefixQuery describes one KV prefix listByPrefix polls: either a
+// default watched prefix (the constructor's prefix or a WithPrefixes
+// addition), whose keys are split into "<set>/<param>" by splitKey or
+// WithKeyTransform, or a WithSetPrefix mapping, whose setName is fixed
+// and whose keys become params of that set directly.
+type prefixQuery struct {
+	prefix     string
+	setName    string // fixed set name from WithSetPrefix, or "" to derive it from the key
+	datacenter string // datacenter override from WithDatacenters, or "" for the provider's default
+}
+
+// waitIxKey identifies this query's blocking-query index in
+// protected.waitIx. It's the prefix alone, unless datacenter is set: the
+// same prefix polled in two datacenters (see WithDatacenters) needs
+// independently tracked indexes, since Consul's indexes aren't
+// comparable across datacenters.
+func (pq prefixQuery) waitIxKey() string {
+	if pq.datacenter == "" {
+		return pq.prefix
+	}
+
+	return pq.datacenter + "|" + pq.prefix
+}
+
+// prefixQueries returns every prefix listByPrefix must poll: the
+// default prefixes() first, then any WithSetPrefix mappings, in the
+// order they were configured. When WithDatacenters is set, every one of
+// those queries is repeated once per configured datacenter, in the
+// order the datacenters were listed - the same "later wins" precedence
+// WithPrefixes and WithSetPrefix already use, so
+// WithDatacenters("primary", "local") merges with local overriding
+// primary.
+func (r *provider) prefixQueries() []prefixQuery {
+	prefixes := r.prefixes()
+	base := make([]prefixQuery, 0, len(prefixes)+len(r.setPrefixes))
+
+	for _, prefix := range prefixes {
+		base = append(base, prefixQuery{prefix: prefix})
+	}
+
+	for _, mapping := range r.setPrefixes {
+		base = append(base, prefixQuery{prefix: mapping.prefix, setName: mapping.setName})
+	}
+
+	if len(r.federatedDatacenters) == 0 {
+		return base
+	}
+
+	queries := make([]prefixQuery, 0, len(base)*len(r.federatedDatacenters))
+	for _, dc := range r.federatedDatacenters {
+		for _, q := range base {
+			queries = append(queries, prefixQuery{prefix: q.prefix, setName: q.setName, datacenter: dc})
+		}
+	}
+
+	return queries
+}
+
+// maxPerKeyWatchKeys is the largest key count WithPerKeyWatch will
+// still watch with individual kv.Get blocking queries. Above this, the
+// per-request overhead of one query per key outweighs the churn saved
+// versus a single kv.List, so list falls back to listByPrefix.
+const maxPerKeyWatchKeys = 8
+
+// list dispatches to listFromCatalog for a provider created with
+// NewFromCatalogServiceMeta, and otherwise to listPerKey or listByPrefix
+// depending on whether WithPerKeyWatch is configured with a small
+// enough key set. WithTxnRead further dispatches the per-key case to
+// listPerKeyTxn.
+func (r *provider) list(ctx context.Context) (types.ParamValues, error) {
+	r.protected.mutex.Lock()
+	r.protected.ignoredKeyCount = 0
+	r.protected.mutex.Unlock()
+
+	ret, err := r.listDispatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.renderTemplates(ret)
+
+	r.protected.mutex.Lock()
+	ignored := r.protected.ignoredKeyCount
+	r.protected.mutex.Unlock()
+
+	r.metrics.setKeyCounts(len(ret), ignored)
+
+	r.logDebugSnapshot(ret)
+
+	return ret, nil
+}
+
+func (r *provider) listDispatch(ctx context.Context) (types.ParamValues, error) {
+	if r.catalogMode {
+		return r.listFromCatalog(ctx)
+	}
+
+	if len(r.perKeyWatchKeys) > 0 && len(r.perKeyWatchKeys) <= maxPerKeyWatchKeys {
+		if r.txnRead {
+			return r.listPerKeyTxn(ctx)
+		}
+
+		return r.listPerKey(ctx)
+	}
+
+	return r.listByPrefix(ctx)
+}
+
+// listByPrefix performs a blocking-query list of every watched prefix,
+// translating the returned KV pairs into a merged types.ParamValues
+// snapshot. When the same set/param is present under more than one
+// prefix, the value from the later prefix wins.
+//
+// Every watch target's blocking query runs concurrently via
+// fetchPrefixes, sharing the provider's single Consul client, so a
+// multi-prefix config's poll latency is bounded by its slowest target
+// rather than the sum of all of them. Merging the fetched results back
+// into ret happens sequentially, in prefixQueries order, so the
+// later-prefix-wins rule above stays deterministic regardless of which
+// target's query actually returned first.
+//
+// Keys are expected to have the shape "<prefix><set>/<param>". Any key
+// with fewer than two segments after the prefix, or with more than
+// splitKey allows (see WithNestedKeys and WithMaxKeyDepth), is skipped
+// and reported via ignoreKey. Keys that don't match a known parameter
+// are skipped as well.
+// Consul "directory" placeholder keys, which end in "/" (e.g. created by
+// browsing the UI), are skipped explicitly rather than left to fail the
+// parameter-name match.
+//
+// A prefix configured through WithSetPrefix is the exception: its
+// setName is fixed, so a key found under it becomes that set's param
+// directly, without a "<set>/" segment to split off.
+//
+// A paramName matching a WithWildcardParam pattern is another exception:
+// instead of requiring a matching ParamNames entry, every matching key's
+// value is collected into a single map-valued parameter. See
+// matchWildcardParam.
+func (r *provider) listByPrefix(ctx context.Context) (types.ParamValues, error) {
+	ret := types.ParamValues{}
+	modifyIndex := make(map[string]uint64)
+	wildcardValues := make(map[string]map[string]string)
+	var unknownKeys []string
+	var rawPairCount int
+	var rawQueryIndex uint64
+
+	results, err := r.fetchPrefixes(ctx, r.prefixQueries())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range results {
+		if res.err != nil {
+			return nil, res.err
+		}
+	}
+
+	for _, res := range results {
+		pq := res.pq
+		prefix := pq.prefix
+		waitIx := res.waitIx
+		pairs := res.pairs
+		meta := res.meta
+
+		var resultIndex uint64
+		if meta != nil {
+			resultIndex = meta.LastIndex
+		}
+
+		rawPairCount += len(pairs)
+		rawQueryIndex = resultIndex
+
+		for _, pair := range pairs {
+			r.processPair(pq, pair, ret, modifyIndex, wildcardValues, &unknownKeys)
+		}
+
+		newWaitIx := nextWaitIndex(waitIx, meta.LastIndex)
+
+		r.protected.mutex.Lock()
+		r.protected.waitIx[pq.waitIxKey()] = newWaitIx
+		r.protected.mutex.Unlock()
+
+		r.metrics.setWaitIndex(newWaitIx)
+		r.adjustAdaptiveWaitTime(meta.LastIndex != waitIx)
+		r.reportPollWake(prefix, waitIx, meta)
+		r.logDebug("consul: polled prefix",
+			String("prefix", prefix),
+			String("datacenter", r.datacenter),
+			Uint64("index", newWaitIx))
+	}
+
+	collectWildcardValues(ret, wildcardValues)
+
+	r.protected.mutex.Lock()
+	r.protected.modifyIndex = modifyIndex
+	r.protected.unknownKeys = unknownKeys
+	r.protected.rawPairCount = rawPairCount
+	r.protected.rawQueryIndex = rawQueryIndex
+	r.protected.mutex.Unlock()
+
+	return ret, nil
+}
+
+// processPair turns one KV pair from prefix's listing into a ret entry,
+// applying stripSegments, key filtering, decoding, JSON/wildcard
+// handling and paramNames matching. It recovers from a panic anywhere
+// in that pipeline (e.g. a user-supplied WithKeyTransform, WithKeyFilter
+// or WithValueDecoder callback) and reports the key ignored instead of
+// letting it escape and fail the whole poll - one malformed key
+// shouldn't keep every other key in the snapshot from being applied.
+func (r *provider) processPair(pq prefixQuery, pair *consulapi.KVPair, ret types.ParamValues, modifyIndex map[string]uint64, wildcardValues map[string]map[string]string, unknownKeys *[]string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.ignoreKey(pair.Key, fmt.Sprintf("panic while processing key: %v", rec))
+		}
+	}()
+
+	prefix := pq.prefix
+
+	k := strings.TrimPrefix(pair.Key, prefix)
+	if k == "" {
+		return
+	}
+
+	if r.stripSegments > 0 {
+		stripped, ok := stripLeadingSegments(k, r.keySeparatorOrDefault(), r.stripSegments)
+		if !ok {
+			r.ignoreKey(pair.Key, "fewer segments than WithStripSegments")
+			return
+		}
+
+		k = stripped
+	}
+
+	if strings.HasSuffix(k, "/") {
+		r.ignoreKey(pair.Key, "directory placeholder key")
+		return
+	}
+
+	if r.ignoreDotKeysEnabled() && r.isDotKey(k) {
+		return
+	}
+
+	if !r.recursiveEnabled() && r.isBeyondImmediateChild(k) {
+		return
+	}
+
+	if r.excludedByPrefix(k) {
+		r.ignoreKey(pair.Key, "excluded by WithExcludePrefixes")
+		return
+	}
+
+	value, ok := r.decodeValue(pair.Key, pair.Value)
+	if !ok {
+		return
+	}
+
+	if r.treatAsUnset(value) {
+		r.ignoreKey(pair.Key, "empty value with EmptyValueAsUnset")
+		return
+	}
+
+	if r.jsonValues && r.applyJSONValue(ret, k, value) {
+		return
+	}
+
+	var setName, paramName string
+
+	switch {
+	case pq.setName != "":
+		setName, paramName = pq.setName, k
+	case r.keyTransform != nil:
+		setName, paramName, ok = r.keyTransform(k)
+		if !ok {
+			r.ignoreKey(pair.Key, "excluded by WithKeyTransform")
+			return
+		}
+	default:
+		setName, paramName, ok = r.splitKey(k)
+		if !ok {
+			r.ignoreKey(pair.Key, "too many segments")
+			return
+		}
+	}
+
+	setName, paramName = r.normalizeKeyCase(setName, paramName)
+
+	if r.keyFilter != nil && !r.keyFilter(setName, paramName) {
+		r.ignoreKey(pair.Key, "filtered out by WithKeyFilter")
+		return
+	}
+
+	if pattern, ok := r.matchWildcardParam(setName, paramName); ok {
+		key := wildcardKey(setName, pattern)
+
+		if wildcardValues[key] == nil {
+			wildcardValues[key] = map[string]string{}
+		}
+
+		wildcardValues[key][paramName] = string(value)
+		modifyIndex[key] = pair.ModifyIndex
+
+		return
+	}
+
+	if !r.paramNames.Get(setName, paramName) {
+		r.ignoreKey(pair.Key, "no matching parameter")
+		*unknownKeys = append(*unknownKeys, pair.Key)
+		return
+	}
+
+	key := setName + "/" + paramName
+	encoded := r.encodeValue(setName, paramName, value)
+	r.reportKeyModified(setName, paramName, key, pair, encoded)
+	ret[key] = encoded
+	modifyIndex[key] = pair.ModifyIndex
+}
+
+// listPerKey watches a small, fixed set of "<set>/<param>" keys with
+// one kv.Get blocking query per key instead of a prefix kv.List. This
+// avoids waking up on writes to unrelated sibling keys under the same
+// prefix, at the cost of one blocking connection per watched key rather
+// than one per prefix - only worth it while the key count stays small,
+// see maxPerKeyWatchKeys.
+func (r *provider) listPerKey(ctx context.Context) (types.ParamValues, error) {
+	ret := types.ParamValues{}
+	modifyIndex := make(map[string]uint64)
+	var unknownKeys []string
+
+	for _, key := range r.perKeyWatchKeys {
+		if r.rateLimiter != nil {
+			if err := r.rateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		setName, paramName, ok := r.splitKey(key)
+		if !ok {
+			return nil, fmt.Errorf("consul: WithPerKeyWatch key %q must have the same <set>/<param> shape as a regular Consul key", key)
+		}
+
+		fullKey := r.currentPrefix() + key
+
+		r.protected.mutex.Lock()
+		waitIx := r.protected.waitIx[fullKey]
+		r.protected.mutex.Unlock()
+
+		var pair *consulapi.KVPair
+		var meta *consulapi.QueryMeta
+		var err error
+
+		for attempt := 0; ; attempt++ {
+			start := time.Now()
+			pair, meta, err = r.currentKV().Get(fullKey, r.queryOptions(ctx, waitIx))
+			r.metrics.observePoll(start, err)
+
+			if err == nil || !isRetryableErr(err) || attempt >= r.listRetryAttempts() {
+				break
+			}
+
+			if sleepErr := r.sleep(ctx, r.listRetryDelay()); sleepErr != nil {
+				err = sleepErr
+				break
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		newWaitIx := nextWaitIndex(waitIx, meta.LastIndex)
+
+		r.protected.mutex.Lock()
+		r.protected.waitIx[fullKey] = newWaitIx
+		r.protected.mutex.Unlock()
+
+		r.metrics.setWaitIndex(newWaitIx)
+		r.adjustAdaptiveWaitTime(meta.LastIndex != waitIx)
+		r.reportPollWake(fullKey, waitIx, meta)
+		r.logDebug("consul: polled key",
+			String("key", fullKey),
+			String("datacenter", r.datacenter),
+			Uint64("index", newWaitIx))
+
+		r.applyPerKeyResult(setName, paramName, fullKey, pair, ret, modifyIndex, &unknownKeys)
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.modifyIndex = modifyIndex
+	r.protected.unknownKeys = unknownKeys
+	r.protected.mutex.Unlock()
+
+	return ret, nil
+}
+
+// applyPerKeyResult turns the KV pair read for a single WithPerKeyWatch
+// key into a ret entry, applying the same decoding, filtering and
+// unknown-key bookkeeping regardless of whether the pair came from a
+// blocking kv.Get (listPerKey) or a kv.Txn read (listPerKeyTxn).
+func (r *provider) applyPerKeyResult(setName, paramName, fullKey string, pair *consulapi.KVPair, ret types.ParamValues, modifyIndex map[string]uint64, unknownKeys *[]string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.ignoreKey(fullKey, fmt.Sprintf("panic while processing key: %v", rec))
+		}
+	}()
+
+	if pair == nil {
+		r.ignoreKey(fullKey, "watched key not found")
+		return
+	}
+
+	value, ok := r.decodeValue(fullKey, pair.Value)
+	if !ok {
+		return
+	}
+
+	if r.treatAsUnset(value) {
+		r.ignoreKey(fullKey, "empty value with EmptyValueAsUnset")
+		return
+	}
+
+	setName, paramName = r.normalizeKeyCase(setName, paramName)
+
+	if r.keyFilter != nil && !r.keyFilter(setName, paramName) {
+		r.ignoreKey(fullKey, "filtered out by WithKeyFilter")
+		return
+	}
+
+	if !r.paramNames.Get(setName, paramName) {
+		r.ignoreKey(fullKey, "no matching parameter")
+		*unknownKeys = append(*unknownKeys, fullKey)
+		return
+	}
+
+	mapKey := setName + "/" + paramName
+	encoded := r.encodeValue(setName, paramName, value)
+	r.reportKeyModified(setName, paramName, mapKey, pair, encoded)
+	ret[mapKey] = encoded
+	modifyIndex[mapKey] = pair.ModifyIndex
+}
+
+// ignoreKey reports a key that list decided not to turn into a
+// parameter, via the OnIgnoredKey callback if one is configured.
+func (r *provider) ignoreKey(fullKey, reason string) {
+	r.protected.mutex.Lock()
+	r.protected.ignoredKeyCount++
+	r.protected.mutex.Unlock()
+
+	if r.onIgnoredKey != nil {
+		r.onIgnoredKey(fullKey, reason)
+	}
+}
+
+// reportPollWake invokes WithOnPollWake, if configured, describing
+// whether this poll's blocking query returned because the index
+// advanced or because it simply timed out with no change.
+func (r *provider) reportPollWake(prefix string, previousIndex uint64, meta *consulapi.QueryMeta) {
+	if r.onPollWake == nil {
+		return
+	}
+
+	r.onPollWake(PollWakeInfo{
+		Prefix:        prefix,
+		Changed:       meta.LastIndex != previousIndex,
+		PreviousIndex: previousIndex,
+		NewIndex:      meta.LastIndex,
+		IndexDelta:    int64(meta.LastIndex) - int64(previousIndex),
+		RequestTime:   meta.RequestTime,
+	})
+}
+
+// reportKeyModified invokes WithOnKeyModified, if configured, when
+// pair's ModifyIndex has advanced past the previously observed one for
+// "<setName>/<paramName>" and its value actually changed - a plain
+// index bump with the same value (e.g. a Consul internal touch) doesn't
+// count as tampering worth reporting.
+func (r *provider) reportKeyModified(setName, paramName, key string, pair *consulapi.KVPair, newValue string) {
+	if r.onKeyModified == nil {
+		return
+	}
+
+	r.protected.mutex.Lock()
+	oldIndex, hadOld := r.protected.modifyIndex[key]
+	oldValue := r.protected.lastApplied[key]
+	r.protected.mutex.Unlock()
+
+	if !hadOld || pair.ModifyIndex <= oldIndex || oldValue == newValue {
+		return
+	}
+
+	r.onKeyModified(KeyModifiedEvent{
+		Set:            setName,
+		Param:          paramName,
+		OldValue:       oldValue,
+		NewValue:       newValue,
+		OldModifyIndex: oldIndex,
+		NewModifyIndex: pair.ModifyIndex,
+		CreateIndex:    pair.CreateIndex,
+		Flags:          pair.Flags,
+	})
+}
+
+// excludedByPrefix reports whether k, a key already trimmed of the
+// watched prefix, falls under one of WithExcludePrefixes's sub-prefixes.
+func (r *provider) excludedByPrefix(k string) bool {
+	for _, excluded := range r.excludePrefixes {
+		if strings.HasPrefix(k, excluded) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeValue runs value through WithValueDecoder, if one is
+// configured. A value that fails to decode is reported via ignoreKey
+// and skipped like any other malformed key, rather than failing the
+// whole poll.
+func (r *provider) decodeValue(fullKey string, value []byte) ([]byte, bool) {
+	if r.valueDecoder == nil {
+		return value, true
+	}
+
+	decoded, err := r.valueDecoder(value)
+	if err != nil {
+		r.ignoreKey(fullKey, fmt.Sprintf("value decode error: %s", err))
+		return nil, false
+	}
+
+	return decoded, true
+}
+
+// stripLeadingSegments removes the first n sep-separated segments from
+// k, for WithStripSegments. It reports ok=false when k has n or fewer
+// segments, so the caller can ignore the key instead of splitting
+// garbage.
+func stripLeadingSegments(k, sep string, n int) (stripped string, ok bool) {
+	if n == 0 {
+		return k, true
+	}
+
+	segments := strings.SplitN(k, sep, n+1)
+	if len(segments) <= n {
+		return "", false
+	}
+
+	return segments[n], true
+}
+
+// keySeparatorOrDefault returns the WithKeySeparator override, or the
+// default "/" when none was configured.
+func (r *provider) keySeparatorOrDefault() string {
+	if r.keySeparator == "" {
+		return "/"
+	}
+
+	return r.keySeparator
+}
+
+// splitKey derives the set/param names from a key already trimmed of
+// its prefix. By default it requires exactly two segments; additional
+// segments, up to maxKeyDepth (see WithMaxKeyDepth) or unbounded under
+// the older WithNestedKeys, are rejoined into the parameter name.
+func (r *provider) splitKey(k string) (setName, paramName string, ok bool) {
+	sep := r.keySeparatorOrDefault()
+
+	keySplitted := strings.Split(k, sep)
+
+	switch {
+	case len(keySplitted) == 2:
+		return keySplitted[0], keySplitted[1], true
+	case len(keySplitted) > 2 && r.allowKeyDepth(len(keySplitted)):
+		return keySplitted[0], strings.Join(keySplitted[1:], sep), true
+	default:
+		return "", "", false
+	}
+}
+
+// allowKeyDepth reports whether a key split into depth segments is
+// permitted beyond the default two-segment "<set>/<param>" shape.
+// WithMaxKeyDepth, when set, is an explicit cap; the older
+// WithNestedKeys keeps its original unbounded behavior when no cap was
+// given, so it continues to work unchanged for existing callers.
+func (r *provider) allowKeyDepth(depth int) bool {
+	if r.maxKeyDepth > 0 {
+		return depth <= r.maxKeyDepth
+	}
+
+	return r.nestedKeys
+}
+
+// normalizeKeyCase lowercases setName/paramName when case-insensitive
+// matching is enabled, so mixed-case Consul keys still resolve to the
+// canonical (lowercase) parameter name proteus expects.
+func (r *provider) normalizeKeyCase(setName, paramName string) (string, string) {
+	if !r.caseInsensitive {
+		return setName, paramName
+	}
+
+	return strings.ToLower(setName), strings.ToLower(paramName)
+}
+
+// nextWaitIndex derives the WaitIndex to use for the next blocking
+// query. Per Consul's blocking-query guidance, an index less than 1 is
+// not valid and must be treated as 0, and so must an index that went
+// backwards relative to the previous query, to avoid a busy-loop.
+func nextWaitIndex(previous, lastIndex uint64) uint64 {
+	if lastIndex < 1 || lastIndex < previous {
+		return 0
+	}
+
+	return lastIndex
+}
@@ -0,0 +1,358 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+var errListFailed = errors.New("list failed")
+
+func TestQueryOptionsDatacenter(t *testing.T) {
+	p := &provider{datacenter: "dc2"}
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.Datacenter != "dc2" {
+		t.Fatalf("Datacenter = %q, want %q", opts.Datacenter, "dc2")
+	}
+}
+
+func TestQueryOptionsDatacenterDefault(t *testing.T) {
+	p := &provider{}
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.Datacenter != "" {
+		t.Fatalf("Datacenter = %q, want empty", opts.Datacenter)
+	}
+}
+
+func TestPrefixesSingle(t *testing.T) {
+	p := &provider{}
+	p.protected.prefix = "app/"
+
+	got := p.prefixes()
+	want := []string{"app/"}
+
+	if len(got) != len(want) || got[0] != want[0] {
+		t.Fatalf("prefixes() = %v, want %v", got, want)
+	}
+}
+
+func TestPrefixesMergeOrder(t *testing.T) {
+	p := &provider{additionalPrefixes: []string{"app/"}}
+	p.protected.prefix = "common/"
+
+	got := p.prefixes()
+	want := []string{"common/", "app/"}
+
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("prefixes() = %v, want %v", got, want)
+	}
+}
+
+func newTestProvider(kv kvBackend, known map[string]bool) *provider {
+	p := &provider{
+		kv:         kv,
+		paramNames: stubParamNames{known: known},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	return p
+}
+
+func TestListMapsKeysToParameters(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+		{Key: "app/other/unknown", Value: []byte("ignored")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" || ret["db/port"] != "5432" {
+		t.Fatalf("ret = %v", ret)
+	}
+
+	if _, ok := ret["other/unknown"]; ok {
+		t.Fatal("list() kept a key that matches no parameter")
+	}
+}
+
+func TestListReportsIgnoredKeys(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host/extra", Value: []byte("x")},
+		{Key: "app/db/unknown", Value: []byte("x")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ignored) != 2 {
+		t.Fatalf("ignored = %v, want 2 entries", ignored)
+	}
+}
+
+func TestListKeyFilterExcludesKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.keyFilter = func(setName, paramName string) bool {
+		return paramName != "port"
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host kept", ret)
+	}
+
+	if _, ok := ret["db/port"]; ok {
+		t.Fatal("list() kept a key excluded by WithKeyFilter")
+	}
+}
+
+func TestListKeyFilterIncludesKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.keyFilter = func(setName, paramName string) bool {
+		return true
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host kept when filter allows it", ret)
+	}
+}
+
+func TestListSkipsDirectoryPlaceholderKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/", Value: nil},
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host kept", ret)
+	}
+
+	if len(ignored) != 1 || ignored[0] != "app/db/: directory placeholder key" {
+		t.Fatalf("ignored = %v", ignored)
+	}
+}
+
+func TestListPropagatesError(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+	p := newTestProvider(kv, nil)
+
+	if _, err := p.list(context.Background()); err != errListFailed {
+		t.Fatalf("err = %v, want %v", err, errListFailed)
+	}
+}
+
+func TestSplitKeyDefaultTwoLevels(t *testing.T) {
+	p := &provider{}
+
+	if _, _, ok := p.splitKey("db/host/replica"); ok {
+		t.Fatal("splitKey accepted a three-segment key by default")
+	}
+
+	setName, paramName, ok := p.splitKey("db/host")
+	if !ok || setName != "db" || paramName != "host" {
+		t.Fatalf("splitKey(%q) = (%q, %q, %v), want (db, host, true)", "db/host", setName, paramName, ok)
+	}
+}
+
+func TestSplitKeyNested(t *testing.T) {
+	p := &provider{nestedKeys: true}
+
+	setName, paramName, ok := p.splitKey("db/replica/host")
+	if !ok || setName != "db" || paramName != "replica/host" {
+		t.Fatalf("splitKey nested = (%q, %q, %v), want (db, replica/host, true)", setName, paramName, ok)
+	}
+
+	setName, paramName, ok = p.splitKey("db/replica/host/port")
+	if !ok || setName != "db" || paramName != "replica/host/port" {
+		t.Fatalf("splitKey deep nested = (%q, %q, %v), want (db, replica/host/port, true)", setName, paramName, ok)
+	}
+}
+
+func TestSplitKeyMaxKeyDepth(t *testing.T) {
+	p := &provider{maxKeyDepth: 3}
+
+	if _, _, ok := p.splitKey("db"); ok {
+		t.Fatal("splitKey accepted a single-segment key")
+	}
+
+	setName, paramName, ok := p.splitKey("db/host")
+	if !ok || setName != "db" || paramName != "host" {
+		t.Fatalf("splitKey(%q) = (%q, %q, %v), want (db, host, true)", "db/host", setName, paramName, ok)
+	}
+
+	setName, paramName, ok = p.splitKey("db/replica/host")
+	if !ok || setName != "db" || paramName != "replica/host" {
+		t.Fatalf("splitKey(%q) = (%q, %q, %v), want (db, replica/host, true)", "db/replica/host", setName, paramName, ok)
+	}
+
+	if _, _, ok := p.splitKey("db/replica/host/port"); ok {
+		t.Fatal("splitKey accepted a four-segment key with WithMaxKeyDepth(3)")
+	}
+}
+
+func TestSplitKeyCustomSeparator(t *testing.T) {
+	p := &provider{keySeparator: "."}
+
+	setName, paramName, ok := p.splitKey("db.host")
+	if !ok || setName != "db" || paramName != "host" {
+		t.Fatalf("splitKey(%q) = (%q, %q, %v), want (db, host, true)", "db.host", setName, paramName, ok)
+	}
+
+	if _, _, ok := p.splitKey("db/host"); ok {
+		t.Fatal("splitKey matched a \"/\"-delimited key while a custom separator is configured")
+	}
+}
+
+func TestWithKeySeparatorRejectsInvalid(t *testing.T) {
+	for _, sep := range []string{"", "/"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("WithKeySeparator(%q) did not panic", sep)
+				}
+			}()
+
+			WithKeySeparator(sep)
+		}()
+	}
+}
+
+func TestNormalizeKeyCase(t *testing.T) {
+	p := &provider{caseInsensitive: true}
+
+	setName, paramName := p.normalizeKeyCase("DB", "Host")
+	if setName != "db" || paramName != "host" {
+		t.Fatalf("normalizeKeyCase = (%q, %q), want (db, host)", setName, paramName)
+	}
+}
+
+func TestNormalizeKeyCaseDisabled(t *testing.T) {
+	p := &provider{}
+
+	setName, paramName := p.normalizeKeyCase("DB", "Host")
+	if setName != "DB" || paramName != "Host" {
+		t.Fatalf("normalizeKeyCase = (%q, %q), want unchanged", setName, paramName)
+	}
+}
+
+func TestQueryOptionsConsistencyMode(t *testing.T) {
+	cases := []struct {
+		mode               ConsistencyMode
+		wantStale          bool
+		wantRequireConsist bool
+	}{
+		{ConsistencyDefault, false, false},
+		{ConsistencyStale, true, false},
+		{ConsistencyConsistent, false, true},
+	}
+
+	for _, tc := range cases {
+		p := &provider{consistencyMode: tc.mode}
+		opts := p.queryOptions(context.Background(), 0)
+
+		if opts.AllowStale != tc.wantStale || opts.RequireConsistent != tc.wantRequireConsist {
+			t.Fatalf("mode %v: AllowStale=%v RequireConsistent=%v, want %v/%v",
+				tc.mode, opts.AllowStale, opts.RequireConsistent, tc.wantStale, tc.wantRequireConsist)
+		}
+	}
+}
+
+func TestNextWaitIndex(t *testing.T) {
+	cases := []struct {
+		name     string
+		previous uint64
+		last     uint64
+		want     uint64
+	}{
+		{"advances normally", 5, 6, 6},
+		{"unchanged", 5, 5, 5},
+		{"goes backwards", 5, 3, 0},
+		{"zero index", 5, 0, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nextWaitIndex(tc.previous, tc.last); got != tc.want {
+				t.Fatalf("nextWaitIndex(%d, %d) = %d, want %d", tc.previous, tc.last, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQueryOptionsNamespace(t *testing.T) {
+	p := &provider{namespace: "team-a"}
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.Namespace != "team-a" {
+		t.Fatalf("Namespace = %q, want %q", opts.Namespace, "team-a")
+	}
+}
+
+func TestQueryOptionsNamespaceAndPartition(t *testing.T) {
+	p := &provider{namespace: "team-a", partition: "eu-west"}
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.Namespace != "team-a" {
+		t.Fatalf("Namespace = %q, want %q", opts.Namespace, "team-a")
+	}
+	if opts.Partition != "eu-west" {
+		t.Fatalf("Partition = %q, want %q", opts.Partition, "eu-west")
+	}
+}
+
+func TestQueryOptionsPartitionDefaultEmpty(t *testing.T) {
+	p := &provider{}
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.Partition != "" {
+		t.Fatalf("Partition = %q, want empty default", opts.Partition)
+	}
+}
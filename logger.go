@@ -0,0 +1,59 @@
+package consul
+
+// Field is a single structured key/value pair attached to a Logger
+// event. Passing prefix, key, datacenter and index as Fields instead of
+// interpolating them into the message lets a structured log aggregator
+// index them.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// String builds a string-valued Field.
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Uint64 builds a uint64-valued Field, used for Consul indexes.
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger receives structured diagnostic events from the provider via
+// WithLogger.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+
+	// DebugEnabled reports whether Debug-level events are actually
+	// going to be recorded. Callers with an expensive Field to build
+	// (e.g. marshaling a large value) should check this first and
+	// skip building it when Debug is disabled.
+	DebugEnabled() bool
+}
+
+// debugEnabled reports whether an expensive Debug-level Field is worth
+// building for the current call.
+func (r *provider) debugEnabled() bool {
+	return r.logger != nil && r.logger.DebugEnabled()
+}
+
+// logDebug is a no-op when no Logger is configured, so call sites don't
+// need to nil-check r.logger themselves.
+func (r *provider) logDebug(msg string, fields ...Field) {
+	if r.logger == nil {
+		return
+	}
+
+	r.logger.Debug(msg, fields...)
+}
+
+// logInfo is a no-op when no Logger is configured, so call sites don't
+// need to nil-check r.logger themselves.
+func (r *provider) logInfo(msg string, fields ...Field) {
+	if r.logger == nil {
+		return
+	}
+
+	r.logger.Info(msg, fields...)
+}
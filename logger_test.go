@@ -0,0 +1,86 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// capturingLogger records every Debug/Info call so tests can assert on
+// the structured fields it received.
+type capturingLogger struct {
+	debugMsgs []string
+	debug     [][]Field
+	infoMsgs  []string
+	info      [][]Field
+	enabled   bool
+}
+
+func (l *capturingLogger) Debug(msg string, fields ...Field) {
+	l.debugMsgs = append(l.debugMsgs, msg)
+	l.debug = append(l.debug, fields)
+}
+
+func (l *capturingLogger) Info(msg string, fields ...Field) {
+	l.infoMsgs = append(l.infoMsgs, msg)
+	l.info = append(l.info, fields)
+}
+
+func (l *capturingLogger) DebugEnabled() bool { return l.enabled }
+
+func fieldValue(fields []Field, key string) (any, bool) {
+	for _, f := range fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+func TestListLogsPolledPrefixWithStructuredFields(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}, lastIndex: 42}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.datacenter = "dc1"
+
+	logger := &capturingLogger{}
+	p.logger = logger
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logger.debug) != 1 {
+		t.Fatalf("got %d Debug calls, want 1", len(logger.debug))
+	}
+
+	fields := logger.debug[0]
+
+	if v, _ := fieldValue(fields, "prefix"); v != "app/" {
+		t.Fatalf("prefix field = %v, want app/", v)
+	}
+
+	if v, _ := fieldValue(fields, "datacenter"); v != "dc1" {
+		t.Fatalf("datacenter field = %v, want dc1", v)
+	}
+
+	if v, _ := fieldValue(fields, "index"); v != uint64(42) {
+		t.Fatalf("index field = %v, want 42", v)
+	}
+}
+
+func TestListNoLoggerConfiguredDoesNothing(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
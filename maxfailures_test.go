@@ -0,0 +1,107 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestUpdateWorkerIterationInvokesOnMaxConsecutiveFailures checks that
+// WithMaxConsecutiveFailures's callback fires exactly once, carrying the
+// error from the failure that tripped the threshold, once
+// updateWorkerIteration has seen that many consecutive errors.
+func TestUpdateWorkerIterationInvokesOnMaxConsecutiveFailures(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.updater = &countingUpdater{}
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+	p.maxConsecutiveFailures = 3
+
+	var calls int
+	var lastErr error
+	p.onMaxConsecutiveFailures = func(err error) {
+		calls++
+		lastErr = err
+	}
+
+	ctx := context.Background()
+	backoff := newBackoff(p.backoffBase, p.backoffMax)
+
+	for i := 0; i < 2; i++ {
+		p.updateWorkerIteration(ctx, backoff)
+	}
+
+	if calls != 0 {
+		t.Fatalf("calls = %d, want 0 before the threshold is reached", calls)
+	}
+
+	p.updateWorkerIteration(ctx, backoff)
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 once the threshold is reached", calls)
+	}
+
+	if lastErr != errListFailed {
+		t.Fatalf("lastErr = %v, want %v", lastErr, errListFailed)
+	}
+
+	// Further failures past the threshold must not re-trigger the
+	// callback until a successful poll resets the count.
+	p.updateWorkerIteration(ctx, backoff)
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want still 1 after a further failure past the threshold", calls)
+	}
+}
+
+// TestUpdateWorkerIterationRearmsOnMaxConsecutiveFailuresAfterRecovery
+// checks that a successful poll resets the failure count, so a later
+// outage re-triggers the callback instead of staying suppressed forever.
+func TestUpdateWorkerIterationRearmsOnMaxConsecutiveFailuresAfterRecovery(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.updater = &countingUpdater{}
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+	p.maxConsecutiveFailures = 2
+
+	var calls int
+	p.onMaxConsecutiveFailures = func(error) { calls++ }
+
+	ctx := context.Background()
+	backoff := newBackoff(p.backoffBase, p.backoffMax)
+
+	for i := 0; i < 2; i++ {
+		p.updateWorkerIteration(ctx, backoff)
+	}
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 after the first outage", calls)
+	}
+
+	// Recover, then fail twice more: the callback must fire again.
+	kv.listErr = nil
+	p.updateWorkerIteration(ctx, backoff)
+
+	kv.listErr = errListFailed
+	for i := 0; i < 2; i++ {
+		p.updateWorkerIteration(ctx, backoff)
+	}
+
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2 after a second outage following recovery", calls)
+	}
+}
+
+func TestWithMaxConsecutiveFailuresPanicsOnInvalidN(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected WithMaxConsecutiveFailures(0, ...) to panic")
+		}
+	}()
+
+	WithMaxConsecutiveFailures(0, func(error) {})
+}
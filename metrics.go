@@ -0,0 +1,104 @@
+package consul
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the Prometheus collectors registered via WithMetrics.
+// A nil *metrics (the default) means metrics collection is disabled
+// with zero overhead.
+type metrics struct {
+	polls       prometheus.Counter
+	pollErrors  prometheus.Counter
+	pollLatency prometheus.Histogram
+	waitIndex   prometheus.Gauge
+	watchedKeys prometheus.Gauge
+	ignoredKeys prometheus.Gauge
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		polls: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "proteus_consul_polls_total",
+			Help: "Number of successful kv.List polls against Consul.",
+		}),
+		pollErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "proteus_consul_poll_errors_total",
+			Help: "Number of failed kv.List polls against Consul.",
+		}),
+		pollLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "proteus_consul_poll_duration_seconds",
+			Help: "Latency of kv.List calls against Consul.",
+		}),
+		waitIndex: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "proteus_consul_wait_index",
+			Help: "Current blocking-query wait index.",
+		}),
+		watchedKeys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "proteus_consul_watched_keys",
+			Help: "Number of keys matched to a known parameter in the last poll.",
+		}),
+		ignoredKeys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "proteus_consul_ignored_keys",
+			Help: "Number of keys skipped in the last poll, e.g. unknown or filtered out.",
+		}),
+	}
+
+	reg.MustRegister(m.polls, m.pollErrors, m.pollLatency, m.waitIndex, m.watchedKeys, m.ignoredKeys)
+
+	return m
+}
+
+func (m *metrics) observePoll(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	m.pollLatency.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		m.pollErrors.Inc()
+
+		return
+	}
+
+	m.polls.Inc()
+}
+
+func (m *metrics) setWaitIndex(ix uint64) {
+	if m == nil {
+		return
+	}
+
+	m.waitIndex.Set(float64(ix))
+}
+
+// setKeyCounts sets, rather than increments, the watched- and
+// ignored-key gauges to the counts observed during the poll that just
+// finished, so each gauge reflects only the most recent poll rather
+// than accumulating across polls.
+func (m *metrics) setKeyCounts(watched, ignored int) {
+	if m == nil {
+		return
+	}
+
+	m.watchedKeys.Set(float64(watched))
+	m.ignoredKeys.Set(float64(ignored))
+}
+
+// WithMetrics registers Prometheus counters for successful/failed
+// polls, a histogram of poll latency, a gauge of the current
+// blocking-query wait index, and gauges of the watched and ignored key
+// counts of the last poll. When no registerer is passed, metrics
+// stay disabled with zero overhead.
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(p *provider) {
+		if registerer == nil {
+			return
+		}
+
+		p.metrics = newMetrics(registerer)
+	}
+}
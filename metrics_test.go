@@ -0,0 +1,60 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsDisabledByDefault(t *testing.T) {
+	var m *metrics
+
+	// Must not panic when metrics are disabled.
+	m.observePoll(time.Now(), nil)
+	m.setWaitIndex(42)
+	m.setKeyCounts(3, 1)
+}
+
+func TestMetricsRecordsPollsAndErrors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newMetrics(reg)
+
+	m.observePoll(time.Now(), nil)
+	m.observePoll(time.Now(), errors.New("boom"))
+
+	if got := testutil.ToFloat64(m.polls); got != 1 {
+		t.Fatalf("polls = %v, want 1", got)
+	}
+
+	if got := testutil.ToFloat64(m.pollErrors); got != 1 {
+		t.Fatalf("pollErrors = %v, want 1", got)
+	}
+}
+
+func TestListSetsWatchedAndIgnoredKeyGauges(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+		{Key: "app/other/unknown", Value: []byte("ignored")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.metrics = newMetrics(prometheus.NewRegistry())
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := testutil.ToFloat64(p.metrics.watchedKeys); got != 2 {
+		t.Fatalf("watchedKeys = %v, want 2", got)
+	}
+
+	if got := testutil.ToFloat64(p.metrics.ignoredKeys); got != 1 {
+		t.Fatalf("ignoredKeys = %v, want 1", got)
+	}
+}
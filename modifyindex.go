@@ -0,0 +1,16 @@
+package consul
+
+// LastModifyIndex returns the Consul ModifyIndex observed for
+// setName/paramName during the most recent successful list, so callers
+// that also use Put can detect concurrent writes (optimistic
+// concurrency) instead of blindly overwriting a value someone else
+// changed. The second return value is false if the parameter hasn't
+// been seen yet.
+func (r *provider) LastModifyIndex(setName, paramName string) (uint64, bool) {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	ix, ok := r.protected.modifyIndex[setName+"/"+paramName]
+
+	return ix, ok
+}
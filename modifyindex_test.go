@@ -0,0 +1,29 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestLastModifyIndexAfterList(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost"), ModifyIndex: 42},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, ok := p.LastModifyIndex("db", "host")
+	if !ok || ix != 42 {
+		t.Fatalf("LastModifyIndex() = (%d, %v), want (42, true)", ix, ok)
+	}
+
+	if _, ok := p.LastModifyIndex("db", "unknown"); ok {
+		t.Fatal("LastModifyIndex() ok = true for a parameter never seen")
+	}
+}
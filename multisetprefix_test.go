@@ -0,0 +1,60 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListMapsSetPrefixesToDistinctSets(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "infra/database/host", Value: []byte("db.internal")},
+		{Key: "infra/redis/host", Value: []byte("cache.internal")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "cache/host": true})
+	p.setPrefixes = []setPrefixMapping{
+		{setName: "db", prefix: "infra/database/"},
+		{setName: "cache", prefix: "infra/redis/"},
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "db.internal" {
+		t.Fatalf("db/host = %q, want %q", ret["db/host"], "db.internal")
+	}
+
+	if ret["cache/host"] != "cache.internal" {
+		t.Fatalf("cache/host = %q, want %q", ret["cache/host"], "cache.internal")
+	}
+}
+
+func TestListIgnoresSetPrefixKeyNotMatchingKnownParam(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "infra/database/unknown", Value: []byte("x")},
+	}}
+
+	var ignored []string
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.setPrefixes = []setPrefixMapping{{setName: "db", prefix: "infra/database/"}}
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey)
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != 0 {
+		t.Fatalf("ret = %+v, want empty", ret)
+	}
+
+	if len(ignored) != 1 || ignored[0] != "infra/database/unknown" {
+		t.Fatalf("ignored = %v, want [infra/database/unknown]", ignored)
+	}
+}
@@ -0,0 +1,131 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// delayedKV is a kvBackend test double whose List call blocks for a
+// per-prefix duration before returning, so tests can prove multiple
+// watch targets are fetched concurrently rather than one after
+// another.
+type delayedKV struct {
+	pairs consulapi.KVPairs
+	delay map[string]time.Duration
+
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (f *delayedKV) List(prefix string, _ *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	f.mutex.Lock()
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mutex.Unlock()
+
+	time.Sleep(f.delay[prefix])
+
+	f.mutex.Lock()
+	f.inFlight--
+	f.mutex.Unlock()
+
+	var matched consulapi.KVPairs
+	for _, pair := range f.pairs {
+		if len(pair.Key) >= len(prefix) && pair.Key[:len(prefix)] == prefix {
+			matched = append(matched, pair)
+		}
+	}
+
+	return matched, &consulapi.QueryMeta{}, nil
+}
+
+func (f *delayedKV) Get(key string, _ *consulapi.QueryOptions) (*consulapi.KVPair, *consulapi.QueryMeta, error) {
+	for _, pair := range f.pairs {
+		if pair.Key == key {
+			return pair, &consulapi.QueryMeta{}, nil
+		}
+	}
+
+	return nil, &consulapi.QueryMeta{}, nil
+}
+
+func (f *delayedKV) Put(p *consulapi.KVPair, _ *consulapi.WriteOptions) (*consulapi.WriteMeta, error) {
+	f.pairs = append(f.pairs, p)
+	return &consulapi.WriteMeta{}, nil
+}
+
+func TestListByPrefixFetchesMultipleWatchTargetsConcurrently(t *testing.T) {
+	kv := &delayedKV{
+		pairs: consulapi.KVPairs{
+			{Key: "app/db/host", Value: []byte("localhost")},
+			{Key: "extra/cache/host", Value: []byte("redis")},
+		},
+		delay: map[string]time.Duration{
+			"app/":   50 * time.Millisecond,
+			"extra/": 50 * time.Millisecond,
+		},
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "cache/host": true})
+	p.additionalPrefixes = []string{"extra/"}
+
+	start := time.Now()
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elapsed := time.Since(start)
+
+	if ret["db/host"] != "localhost" || ret["cache/host"] != "redis" {
+		t.Fatalf("ret = %v, want values from both watch targets", ret)
+	}
+
+	if elapsed >= 90*time.Millisecond {
+		t.Fatalf("list() took %s, want close to the single 50ms delay, not the sum of both prefixes' delays", elapsed)
+	}
+
+	kv.mutex.Lock()
+	maxInFlight := kv.maxInFlight
+	kv.mutex.Unlock()
+
+	if maxInFlight < 2 {
+		t.Fatalf("maxInFlight = %d, want both prefixes' List calls to overlap", maxInFlight)
+	}
+}
+
+func TestListByPrefixSinglePrefixStillWorks(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListByPrefixPropagatesErrorFromAnyWatchTarget(t *testing.T) {
+	kv := &fakeKV{listErr: errListFailed}
+
+	p := newTestProvider(kv, map[string]bool{})
+	p.additionalPrefixes = []string{"extra/"}
+
+	if _, err := p.list(context.Background()); err == nil {
+		t.Fatal("list() did not propagate the error from a failing watch target")
+	}
+}
@@ -0,0 +1,29 @@
+package consul
+
+import (
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestNewWithClientSkipsClientConstruction(t *testing.T) {
+	client, err := consulapi.NewClient(consulapi.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewWithClient(client, "app/", WithWaitTime(time.Second)).(*provider)
+
+	if p.consulURLFn != nil {
+		t.Fatal("NewWithClient must not set consulURLFn")
+	}
+
+	if p.client != client {
+		t.Fatal("NewWithClient must store the given client verbatim")
+	}
+
+	if p.waitTime != time.Second {
+		t.Fatalf("waitTime = %s, want options still applied", p.waitTime)
+	}
+}
@@ -0,0 +1,872 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/sources"
+	"github.com/simplesurance/proteus/types"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// Option customizes a provider created by New, NewFromReference or a
+// similar constructor.
+type Option func(*provider)
+
+// WithACLToken configures the Consul ACL token used to authenticate
+// requests against a secured Consul cluster. An empty token (the
+// default) preserves today's unauthenticated behavior.
+func WithACLToken(token string) Option {
+	return func(p *provider) {
+		p.token = token
+	}
+}
+
+// WithDatacenter targets a specific Consul datacenter for KV reads.
+// The default of an empty string means "local datacenter", matching
+// today's behavior.
+func WithDatacenter(datacenter string) Option {
+	return func(p *provider) {
+		p.datacenter = datacenter
+	}
+}
+
+// TLSOptions configures TLS when connecting to an HTTPS Consul
+// endpoint. The zero value changes nothing versus plain consul.Config
+// defaults.
+type TLSOptions struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// WithTLS configures TLS settings used when the Consul URL uses
+// https://.
+func WithTLS(tlsOpts TLSOptions) Option {
+	return func(p *provider) {
+		p.tls = tlsOpts
+	}
+}
+
+// WithNamespace selects a Consul Enterprise namespace for KV reads. The
+// default of an empty string means the default namespace, matching
+// today's behavior. Consul OSS ignores this setting.
+func WithNamespace(namespace string) Option {
+	return func(p *provider) {
+		p.namespace = namespace
+	}
+}
+
+// WithNamespaceReference selects a Consul Enterprise namespace whose
+// value is resolved from another proteus provider's parameter, so the
+// namespace can be chained the same way ConsulURL can.
+func WithNamespaceReference(ref *sources.Reference) Option {
+	return func(p *provider) {
+		p.namespaceRef = ref
+	}
+}
+
+// WithPartition selects a Consul Enterprise admin partition for KV
+// reads. The default of an empty string means the default partition,
+// matching today's behavior. Consul OSS ignores this setting. Partition
+// and WithNamespace/WithNamespaceReference can be combined freely - a
+// namespace lives inside a partition, not the other way around.
+func WithPartition(partition string) Option {
+	return func(p *provider) {
+		p.partition = partition
+	}
+}
+
+// WithPartitionReference selects a Consul Enterprise admin partition
+// whose value is resolved from another proteus provider's parameter, so
+// the partition can be chained the same way ConsulURL can.
+func WithPartitionReference(ref *sources.Reference) Option {
+	return func(p *provider) {
+		p.partitionRef = ref
+	}
+}
+
+// WithWaitTime overrides the WaitTime used for the blocking list query,
+// replacing the default of one minute. duration must be positive; a
+// zero or negative value panics at construction time. A duration
+// greater than Consul's own 10-minute maximum is silently clamped to
+// it - the server would clamp it anyway, and clamping here keeps our
+// own timeout/index logic (see consulWaitPadding, WithHTTPTimeout) in
+// sync with what's actually sent instead of reasoning about a value
+// Consul will never honor.
+func WithWaitTime(duration time.Duration) Option {
+	if duration <= 0 {
+		panic(fmt.Sprintf("consul: WithWaitTime requires a positive duration, got %s", duration))
+	}
+
+	if duration > maxConsulWaitTime {
+		duration = maxConsulWaitTime
+	}
+
+	return func(p *provider) {
+		p.waitTime = duration
+	}
+}
+
+// WithAdaptiveWaitTime makes the blocking query's WaitTime shrink
+// toward min right after a change is observed, and grow back toward
+// max during quiet polls, instead of staying fixed at WithWaitTime.
+// This keeps the provider responsive during bursts of Consul writes
+// while falling back to a longer, cheaper poll interval once things go
+// quiet again. It takes precedence over WithWaitTime while active.
+//
+// min and max must both be positive and min must not exceed max; either
+// violation panics at construction time.
+func WithAdaptiveWaitTime(min, max time.Duration) Option {
+	if min <= 0 || max <= 0 {
+		panic(fmt.Sprintf("consul: WithAdaptiveWaitTime requires positive bounds, got min=%s max=%s", min, max))
+	}
+
+	if min > max {
+		panic(fmt.Sprintf("consul: WithAdaptiveWaitTime requires min <= max, got min=%s max=%s", min, max))
+	}
+
+	return func(p *provider) {
+		p.adaptiveMinWait = min
+		p.adaptiveMaxWait = max
+	}
+}
+
+// WithRetryBackoff configures the exponential backoff used between
+// reconnect attempts after a failed poll, replacing the fixed 5 second
+// delay. The delay starts at base, doubles on every consecutive
+// failure up to max, and resets to base after a successful poll.
+func WithRetryBackoff(base, max time.Duration) Option {
+	return func(p *provider) {
+		p.backoffBase = base
+		p.backoffMax = max
+	}
+}
+
+// WithPrefixes watches additional KV prefixes besides the one passed to
+// the constructor. When a key exists under more than one prefix, the
+// value from the prefix that appears later in this list wins.
+func WithPrefixes(prefixes ...string) Option {
+	return func(p *provider) {
+		for _, prefix := range prefixes {
+			p.additionalPrefixes = append(p.additionalPrefixes, normalizePrefix(prefix))
+		}
+	}
+}
+
+// setPrefixMapping backs WithSetPrefix, see list.go's prefixQueries.
+type setPrefixMapping struct {
+	setName string
+	prefix  string
+}
+
+// WithSetPrefix overrides the watched prefix for a single parameter
+// set, instead of deriving that set's keys from the shared prefix
+// passed to the constructor. Keys found under prefix become params of
+// setName directly, with no "<set>/" segment: prefix
+// "infra/database/" mapped to set "db" turns key
+// "infra/database/host" into param "db/host".
+//
+// Call it once per set that needs its own Consul folder; any set
+// without a WithSetPrefix mapping keeps coming from the default
+// watched prefix (and WithPrefixes) as before. This is unrelated to the
+// SetPrefix method, which changes the single default prefix at runtime.
+func WithSetPrefix(setName, prefix string) Option {
+	return func(p *provider) {
+		p.setPrefixes = append(p.setPrefixes, setPrefixMapping{setName: setName, prefix: normalizePrefix(prefix)})
+	}
+}
+
+// WithDatacenters watches every configured prefix (the constructor's
+// prefix, WithPrefixes and WithSetPrefix) once per listed datacenter
+// instead of only in WithDatacenter's single datacenter, merging the
+// results the same way WithPrefixes already does: when a key exists in
+// more than one datacenter, the value from the datacenter that appears
+// later in datacenters wins. For a primary and a local datacenter,
+// WithDatacenters("primary-dc", "local-dc") makes local override
+// primary; reverse the order for the opposite precedence. Each
+// datacenter's blocking query tracks its own index independently, since
+// Consul's indexes aren't comparable across datacenters. WithDatacenter
+// still selects the datacenter used for direct calls like Get and Put.
+func WithDatacenters(datacenters ...string) Option {
+	return func(p *provider) {
+		p.federatedDatacenters = append(p.federatedDatacenters, datacenters...)
+	}
+}
+
+// WithWildcardParam registers a glob pattern (as understood by
+// path.Match, e.g. "flag.*") that matches many Consul paramNames under
+// setName, for applications with dynamically named parameters (e.g.
+// per-feature flags) that can't predeclare every key with the
+// application's ParamNames.
+//
+// Matching keys bypass the usual ParamNames.Get check and are instead
+// collected into a single JSON object value - {"foo":"1","bar":"2"}
+// for pattern "flag.*" matching Consul keys "flag.foo" and "flag.bar" -
+// assigned to the parameter named setName+"/"+pattern, which the
+// application decodes itself with encoding/json. This trades away the
+// missing-key/wrong-type validation a regular declared parameter gets.
+func WithWildcardParam(setName, pattern string) Option {
+	return func(p *provider) {
+		p.wildcardParams = append(p.wildcardParams, wildcardParam{setName: setName, pattern: pattern})
+	}
+}
+
+// WithOnUpdate registers a callback invoked after every successful
+// Update, receiving the full set of values that were just applied.
+// It is called from the worker goroutine, after the updater has
+// already been notified, and must not block.
+func WithOnUpdate(fn func(types.ParamValues)) Option {
+	return func(p *provider) {
+		p.onUpdate = fn
+	}
+}
+
+// WithNestedKeys allows keys with more than two path segments under a
+// prefix. Segments after the first (the set name) are rejoined with
+// "/" into the parameter name, e.g. "app/db/replica/host" becomes set
+// "db", parameter "replica/host". The default remains the two-level
+// "<set>/<param>" shape, so existing deployments are unaffected.
+func WithNestedKeys() Option {
+	return func(p *provider) {
+		p.nestedKeys = true
+	}
+}
+
+// WithMaxKeyDepth caps the number of "/"-separated segments (or
+// WithKeySeparator-separated, if set) a key under a prefix may have,
+// generalizing the hardcoded two-segment "<set>/<param>" shape. A key
+// with exactly two segments is always accepted regardless of this
+// option. Segments beyond the second, up to n, are rejoined into the
+// parameter name the same way WithNestedKeys does; a key deeper than n
+// is rejected as unknown, same as today's default of 2. n must be at
+// least 2.
+func WithMaxKeyDepth(n int) Option {
+	if n < 2 {
+		panic(fmt.Sprintf("consul: WithMaxKeyDepth requires n >= 2, got %d", n))
+	}
+
+	return func(p *provider) {
+		p.maxKeyDepth = n
+	}
+}
+
+// WithStripSegments removes n additional "/"-separated (or
+// WithKeySeparator-separated) leading segments from a key after the
+// watched prefix has been trimmed off, before the set/param split runs.
+// It's useful when the watched prefix is itself nested, e.g.
+// "env/prod/myapp/", but set/param derivation should start below an
+// extra fixed segment such as a region or shard name that isn't part of
+// the prefix every provider instance watches. A key with n or fewer
+// segments is skipped and reported via WithOnIgnoredKey, the same as a
+// key that fails the set/param split. n must be at least 0.
+func WithStripSegments(n int) Option {
+	if n < 0 {
+		panic(fmt.Sprintf("consul: WithStripSegments requires n >= 0, got %d", n))
+	}
+
+	return func(p *provider) {
+		p.stripSegments = n
+	}
+}
+
+// WithKeySeparator overrides the delimiter used to split a Consul key
+// into its set and parameter name, replacing the default "/". sep must
+// be non-empty and must not itself be "/", since the prefix trim
+// always operates on "/"-delimited paths.
+func WithKeySeparator(sep string) Option {
+	if sep == "" {
+		panic("consul: WithKeySeparator requires a non-empty separator")
+	}
+
+	if sep == "/" {
+		panic("consul: WithKeySeparator must not be \"/\", which is reserved for the prefix path")
+	}
+
+	return func(p *provider) {
+		p.keySeparator = sep
+	}
+}
+
+// WithCaseInsensitiveKeys normalizes set and parameter names derived
+// from Consul keys to lowercase before matching them against proteus's
+// registered parameters, so operator-supplied mixed-case keys like
+// "DB/Host" are still recognized. The value is still stored under the
+// canonical (lowercase) parameter name proteus expects.
+func WithCaseInsensitiveKeys() Option {
+	return func(p *provider) {
+		p.caseInsensitive = true
+	}
+}
+
+// WithStartupTimeout makes Watch retry the initial list with backoff
+// for up to duration before giving up, instead of failing on the first
+// error. This absorbs transient startup races (e.g. Consul not yet
+// reachable) without crashing the application. The default of zero
+// preserves today's single-attempt behavior.
+func WithStartupTimeout(duration time.Duration) Option {
+	return func(p *provider) {
+		p.startupTimeout = duration
+	}
+}
+
+// WithTokenFile reads the Consul ACL token from path at startup and
+// re-reads it whenever a poll fails with a Consul ACL/permission
+// error, rebuilding the client with the new token. This supports
+// rotating tokens delivered as mounted secret files without a restart.
+func WithTokenFile(path string) Option {
+	return func(p *provider) {
+		p.tokenFile = path
+	}
+}
+
+// WithCacheFile enables a warm-cache fallback backed by path: every
+// successful poll writes its snapshot to path, and if Watch's initial
+// list fails - e.g. Consul is unreachable at startup - the last
+// snapshot written there is loaded and used instead, so the
+// application can still start with stale-but-usable configuration
+// while the background worker keeps retrying Consul. Without
+// WithCacheFile, an initial list failure still fails Watch as before.
+func WithCacheFile(path string) Option {
+	return func(p *provider) {
+		p.cacheFilePath = path
+	}
+}
+
+// WithJSONValues allows a whole parameter set to be stored as a single
+// JSON object at one Consul key instead of one key per parameter. When
+// a key's value parses as a JSON object, its top-level fields are
+// expanded into parameters of the set named after that key. Values
+// that aren't a JSON object fall through to the regular string
+// handling.
+func WithJSONValues() Option {
+	return func(p *provider) {
+		p.jsonValues = true
+	}
+}
+
+// ConsistencyMode selects the read consistency used for KV queries.
+type ConsistencyMode int
+
+const (
+	// ConsistencyDefault uses Consul's default consistency: the query
+	// is forwarded to the leader.
+	ConsistencyDefault ConsistencyMode = iota
+	// ConsistencyStale allows the query to be served by any server,
+	// including followers, reducing leader load at the cost of
+	// possibly stale data.
+	ConsistencyStale
+	// ConsistencyConsistent forces the query through the full
+	// consensus protocol, at the cost of extra latency.
+	ConsistencyConsistent
+)
+
+// WithConsistencyMode selects the read consistency mode used for KV
+// queries. The default, ConsistencyDefault, matches today's behavior.
+func WithConsistencyMode(mode ConsistencyMode) Option {
+	return func(p *provider) {
+		p.consistencyMode = mode
+	}
+}
+
+// EmptyValuePolicy selects how list treats a key whose Consul value is
+// the empty string.
+type EmptyValuePolicy int
+
+const (
+	// EmptyValueAsEmpty passes an empty pair.Value through as an empty
+	// parameter string, the same as today's behavior.
+	EmptyValueAsEmpty EmptyValuePolicy = iota
+	// EmptyValueAsUnset skips a key whose value is empty, the same as
+	// if the key didn't exist, so the parameter keeps its default
+	// instead of being explicitly set to "".
+	EmptyValueAsUnset
+)
+
+// WithEmptyValuePolicy selects how list treats a key whose Consul value
+// is the empty string. The default, EmptyValueAsEmpty, matches today's
+// behavior.
+func WithEmptyValuePolicy(policy EmptyValuePolicy) Option {
+	return func(p *provider) {
+		p.emptyValuePolicy = policy
+	}
+}
+
+// WithOnIgnoredKey registers a callback invoked for each key skipped
+// by list, with the full Consul key and a short human-readable reason
+// (e.g. "too many segments", "no matching parameter"). This lets
+// operators surface misconfigured keys in their own dashboards.
+func WithOnIgnoredKey(fn func(fullKey, reason string)) Option {
+	return func(p *provider) {
+		p.onIgnoredKey = fn
+	}
+}
+
+// WithKeyFilter restricts which keys list turns into parameters. fn is
+// consulted with the already case-normalized set/param names, before
+// the paramNames.Get match against proteus's registered parameters, so
+// it can exclude keys from a large shared prefix without needing to
+// know which parameters proteus registered. Returning false skips the
+// key entirely, as if it didn't match a known parameter, which also
+// keeps it from causing blocking-query churn on unrelated writes if it
+// were later joined to a per-key watch. The default of nil keeps every
+// key that already matches paramNames.
+func WithKeyFilter(fn func(setName, paramName string) bool) Option {
+	return func(p *provider) {
+		p.keyFilter = fn
+	}
+}
+
+// WithHTTPBasicAuth configures HTTP basic auth credentials sent with
+// every request, for Consul endpoints that sit behind a reverse proxy
+// requiring them. Consul itself ignores basic auth unless a proxy in
+// front of it checks it.
+func WithHTTPBasicAuth(user, pass string) Option {
+	return func(p *provider) {
+		p.httpAuth = &consulapi.HttpBasicAuth{Username: user, Password: pass}
+	}
+}
+
+// WithTransportTuning replaces the default pooled HTTP transport
+// (cleanhttp.DefaultPooledTransport) with one built from opts,
+// controlling connection reuse and HTTP/2 negotiation for services
+// making frequent blocking queries. Has no effect for a unix-socket
+// address, which always uses its own transport, or on a provider built
+// via NewWithClient, since that client is used verbatim.
+func WithTransportTuning(opts TransportOptions) Option {
+	return func(p *provider) {
+		p.transportTuning = &opts
+	}
+}
+
+// WithHTTPHeader adds a fixed HTTP header sent with every request, for
+// reverse proxies in front of Consul that require one. Calling it more
+// than once with different keys accumulates headers; calling it again
+// with the same key overwrites the previous value.
+func WithHTTPHeader(key, value string) Option {
+	return func(p *provider) {
+		if p.httpHeaders == nil {
+			p.httpHeaders = make(map[string]string)
+		}
+
+		p.httpHeaders[key] = value
+	}
+}
+
+// WithHTTPTimeout sets a timeout on the underlying HTTP client used to
+// talk to Consul, guarding against a hung connection stalling the
+// worker on a flaky network. duration must be positive. Since blocking
+// list queries hold the connection open for up to the effective
+// WaitTime (see WithWaitTime) plus the random padding of up to
+// WaitTime/16 that Consul adds, duration must also be strictly larger
+// than that sum, or Watch returns an error instead of a client that
+// would time out on polls.
+func WithHTTPTimeout(duration time.Duration) Option {
+	if duration <= 0 {
+		panic(fmt.Sprintf("consul: WithHTTPTimeout requires a positive duration, got %s", duration))
+	}
+
+	return func(p *provider) {
+		p.httpTimeout = duration
+	}
+}
+
+// WithReadReplica points Get and Keys at a separate Consul HTTP API
+// address instead of the primary client the watch loop uses, so
+// high-volume ad-hoc reads don't compete with the watch's blocking
+// queries for connections to the same server. addr is typically a
+// follower or a load balancer in front of the cluster; combine it with
+// WithConsistencyMode(ConsistencyStale) if that address may itself
+// route to a follower, since reads through it can then lag the leader
+// by however far that follower's replication is behind. All other
+// connection options (WithACLToken, WithTLS, WithDatacenter, ...)
+// apply to both clients. Watch still uses the primary client
+// exclusively; this only affects Get and Keys.
+func WithReadReplica(addr string) Option {
+	return func(p *provider) {
+		p.readReplicaAddr = addr
+	}
+}
+
+// WithListRetries configures how list retries a single prefix's
+// kv.List (or kv.Get, under WithPerKeyWatch) when it fails with a
+// transient error - a temporary/timeout network error or an HTTP 5xx -
+// before giving up and letting the failure trigger the worker's normal
+// reconnect backoff. Non-retryable errors (e.g. a 403 ACL denial) and
+// context cancellation are still returned immediately. The defaults of
+// zero mean defaultListRetries attempts spaced defaultListRetryDelay
+// apart.
+func WithListRetries(attempts int, delay time.Duration) Option {
+	return func(p *provider) {
+		p.listRetries = attempts
+		p.retryDelay = delay
+	}
+}
+
+// WithStrictUnknownKeys makes the provider treat Consul keys under the
+// prefix that match no registered parameter as a configuration
+// mistake (e.g. a typo in a key name) instead of something to quietly
+// skip. When failOnUnknown is true, such a key found during the
+// initial list makes Watch return an error instead of starting the
+// worker. Unknown keys found by later polls don't stop the worker -
+// since it's already running - but are still reported to
+// WithOnIgnoredKey, if configured, the same as without this option.
+func WithStrictUnknownKeys(failOnUnknown bool) Option {
+	return func(p *provider) {
+		p.strictUnknownKeys = failOnUnknown
+	}
+}
+
+// WithIgnoreDotKeys controls whether a Consul key with a
+// "."-prefixed segment (e.g. ".keep" or ".lock", the kind of
+// housekeeping entry the Consul UI or other tooling leaves behind
+// under a watched prefix) is silently skipped instead of going through
+// the normal unknown-key handling and its WithOnIgnoredKey reporting.
+// This filtering is enabled by default; call WithIgnoreDotKeys(false)
+// to disable it, e.g. because a real parameter legitimately needs a
+// dot-prefixed key segment.
+func WithIgnoreDotKeys(enabled bool) Option {
+	return func(p *provider) {
+		p.dotKeysDisabled = !enabled
+	}
+}
+
+// WithRecursive controls whether keys deeper than a watched prefix's
+// immediate children are turned into parameters. Call
+// WithRecursive(false) so a key that lives deeper in the subtree is
+// skipped entirely, the same as a dot-prefixed key under
+// WithIgnoreDotKeys, rather than being reported through
+// WithOnIgnoredKey.
+//
+// This is filtering applied to the result of the same recursive
+// kv.List Consul call listByPrefix always makes - the kvBackend
+// interface has no non-recursive list operation to call instead, so a
+// write deep in the subtree still advances the index the blocking
+// query wakes on, and still triggers a full re-fetch of the whole
+// subtree over the wire. WithRecursive(false) only reduces which keys
+// become parameters; it does not reduce polling traffic.
+func WithRecursive(recursive bool) Option {
+	return func(p *provider) {
+		p.nonRecursive = !recursive
+	}
+}
+
+// WithTokenProvider configures a pluggable source of Consul ACL tokens,
+// consulted for a fresh token whenever a poll fails with an ACL error,
+// before the client is rebuilt. It takes precedence over WithTokenFile
+// when both are set. Use StaticToken for a fixed token, or implement
+// TokenProvider against Vault's Consul secrets engine or a similar
+// source of short-lived, auto-renewed tokens.
+func WithTokenProvider(tp TokenProvider) Option {
+	return func(p *provider) {
+		p.tokenProvider = tp
+	}
+}
+
+// WithContext ties the provider's lifetime to ctx, so cancelling it
+// stops the background worker started by Watch the same way calling
+// Stop does. Stop remains available as a second, independent
+// cancellation path; whichever fires first wins. The default of nil
+// behaves like context.Background(), matching today's behavior.
+func WithContext(ctx context.Context) Option {
+	return func(p *provider) {
+		p.baseCtx = ctx
+	}
+}
+
+// WithPerKeyWatch watches exactly the given "<set>/<param>" keys with
+// individual kv.Get blocking queries instead of a single prefix
+// kv.List, for services that only need a handful of parameters out of
+// a large shared prefix. Each watched key gets its own blocking
+// connection and only wakes up on writes to that key, avoiding the
+// churn a List sees from unrelated sibling keys.
+//
+// The tradeoff is per-key overhead: watching many keys this way opens
+// many concurrent blocking connections instead of one. Once more than
+// maxPerKeyWatchKeys keys are given, list transparently falls back to
+// the regular prefix List so callers don't have to pick the strategy
+// themselves.
+func WithPerKeyWatch(keys ...string) Option {
+	return func(p *provider) {
+		p.perKeyWatchKeys = keys
+	}
+}
+
+// WithWriteAccess enables Put, allowing the provider to write KV
+// values back to Consul. Without it, Put returns
+// ErrWriteAccessDisabled, so the common read-only usage can't
+// accidentally write.
+func WithWriteAccess() Option {
+	return func(p *provider) {
+		p.writable = true
+	}
+}
+
+// WithEnvDefaults makes Watch fall back to the standard CONSUL_HTTP_ADDR
+// and CONSUL_HTTP_TOKEN environment variables, the same ones the Consul
+// CLI and most other Consul tooling read, for the address and token
+// when they weren't otherwise provided. This eases adoption for
+// deployments that already export those variables; without this
+// option, the environment is never consulted, matching today's
+// behavior. An explicitly configured address (New, NewFromReference) or
+// WithACLToken/WithTokenFile always takes precedence over the
+// environment.
+func WithEnvDefaults() Option {
+	return func(p *provider) {
+		p.envDefaults = true
+	}
+}
+
+// WithDeferInitialApply makes Watch push an empty types.ParamValues to
+// updater.Update instead of the values found by its initial list, and
+// makes the update worker's first poll deliver the real initial values
+// once it starts, the same way it would deliver any other detected
+// change.
+//
+// This exists for chained setups where a caller wants to coordinate the
+// first real apply across several providers instead of having each one
+// apply as soon as its own Watch returns. Ordering implications: Watch
+// returning successfully no longer means the caller's configuration
+// reflects Consul - it means the worker has started and the real
+// values will follow asynchronously through the same onUpdate/onDelta
+// callbacks as any later change. Callers relying on Watch to mean
+// "config is loaded" must not use this option.
+func WithDeferInitialApply() Option {
+	return func(p *provider) {
+		p.deferInitialApply = true
+	}
+}
+
+// WithValueTemplating enables Go text/template rendering of values that
+// contain "{{", after list has collected every raw value in the poll.
+// A template's data is the full raw snapshot, so a value can reference
+// a sibling parameter with {{index . "set/param"}}, e.g. composing a
+// connection string from separately configured host and port keys. A
+// template that fails to parse or execute is logged and its raw text
+// is used instead, see renderTemplates.
+func WithValueTemplating() Option {
+	return func(p *provider) {
+		p.valueTemplating = true
+	}
+}
+
+// WithMaxConsecutiveFailures calls onExceed once the update worker has
+// seen n consecutive poll failures since its last successful list,
+// passing the error from the failure that tripped the threshold.
+//
+// By default a provider retries failed polls forever in the background
+// with no way for the application to notice a prolonged outage. Some
+// deployments would rather have the process exit and let an
+// orchestrator reschedule it than keep serving stale configuration
+// indefinitely; onExceed is the hook for that, e.g. calling
+// os.Exit or canceling the application's own context. It fires once per
+// outage, not on every failed attempt past n - a following successful
+// poll resets the count and re-arms it. n must be at least 1; the
+// default of zero preserves the existing infinite-retry behavior.
+func WithMaxConsecutiveFailures(n int, onExceed func(error)) Option {
+	if n < 1 {
+		panic(fmt.Sprintf("consul: WithMaxConsecutiveFailures requires n >= 1, got %d", n))
+	}
+
+	return func(p *provider) {
+		p.maxConsecutiveFailures = n
+		p.onMaxConsecutiveFailures = onExceed
+	}
+}
+
+// WithStartupJitter sleeps a random duration between 0 and max before
+// Watch performs its initial list, so that many instances started at
+// the same time (e.g. a rolling deploy) don't all hit Consul's kv.List
+// in the same instant. The sleep respects context cancellation the
+// same way the worker's backoff sleeps do. The default of zero
+// preserves immediate startup.
+func WithStartupJitter(max time.Duration) Option {
+	return func(p *provider) {
+		p.startupJitter = max
+	}
+}
+
+// WithDebounce coalesces updates seen within window into a single
+// Update call, for prefixes that see bursts of KV writes (e.g. a
+// deploy tool writing several keys back to back) which would otherwise
+// thrash downstream UpdateFns with one Update per intermediate state.
+// Each change seen within the window replaces the pending snapshot and
+// restarts the window; only the latest snapshot is ever delivered,
+// once window elapses without a newer change arriving. The default of
+// zero delivers every change immediately, as without this option.
+func WithDebounce(window time.Duration) Option {
+	return func(p *provider) {
+		p.debounce = window
+	}
+}
+
+// WithKeyTransform replaces the default "<set>/<param>" splitting of a
+// Consul key (after the watched prefix is trimmed off) with a custom
+// function, for prefixes that use a different key layout - e.g. flat
+// environment-variable-style names like "DB_HOST" mapped to set "db",
+// param "host". It runs in place of splitKey; the transform owns the
+// full raw key and returns ok=false to exclude a key, the same as a
+// key that doesn't match the default two-segment shape.
+func WithKeyTransform(fn func(rawKey string) (setName, paramName string, ok bool)) Option {
+	return func(p *provider) {
+		p.keyTransform = fn
+	}
+}
+
+// WithOnPollWake registers a callback invoked after every blocking-query
+// poll of a watched prefix (or, under WithPerKeyWatch, of a watched
+// key), reporting whether Consul's index actually advanced or the call
+// simply returned because WaitTime elapsed with no change. This is
+// separate from WithOnUpdate, which only fires when the merged
+// parameter values actually change: a prefix's index can advance (e.g.
+// a write to an unrelated sibling key) without producing an Update, and
+// this callback helps tell that apart from a plain timeout when
+// diagnosing noisy neighbors sharing the prefix.
+func WithOnPollWake(fn func(PollWakeInfo)) Option {
+	return func(p *provider) {
+		p.onPollWake = fn
+	}
+}
+
+// WithOnEmptyPrefix registers a callback invoked once, after Watch's
+// initial list, if the watched prefix matched zero KV pairs. This is a
+// common symptom of a misconfigured prefix (typo, wrong environment),
+// which would otherwise pass silently since an empty prefix is a valid
+// (if unusual) result on its own. See checkEmptyPrefix.
+func WithOnEmptyPrefix(fn func()) Option {
+	return func(p *provider) {
+		p.onEmptyPrefix = fn
+	}
+}
+
+// WithRateLimiter makes list wait on limiter before issuing each
+// kv.List (or kv.Get, under WithPerKeyWatch) call, bounding how often
+// this process hits Consul - useful when several providers, or several
+// instances of the same application, could otherwise reconnect and poll
+// in lockstep after an outage and overwhelm the server. The wait honors
+// context cancellation, returning its error instead of blocking
+// forever. The default of nil issues requests as fast as the blocking
+// queries themselves allow, matching today's behavior.
+func WithRateLimiter(limiter *rate.Limiter) Option {
+	return func(p *provider) {
+		p.rateLimiter = limiter
+	}
+}
+
+// WithTxnRead makes WithPerKeyWatch read its keys through a kv.Txn
+// transaction instead of one blocking kv.Get per key, so that every key
+// in a batch is read at the same Raft index. See listPerKeyTxn for the
+// batching and blocking-query caveats this trades off. It has no effect
+// without WithPerKeyWatch: kv.Txn has no verb for reading a whole
+// prefix, so it cannot help the ordinary prefix-based list.
+func WithTxnRead() Option {
+	return func(p *provider) {
+		p.txnRead = true
+	}
+}
+
+// WithLogger makes the provider report its polling activity to logger,
+// with prefix, key, datacenter and index passed as structured Fields
+// rather than baked into the message string. Since all options are
+// applied before the constructor returns, logger also receives any
+// construction-time validation warnings (see logConstructionWarnings)
+// regardless of where WithLogger appears in the opts list.
+func WithLogger(logger Logger) Option {
+	return func(p *provider) {
+		p.logger = logger
+	}
+}
+
+// WithFallbackAddresses supplies secondary Consul addresses to fail over
+// to after repeated errors against the currently active one, see
+// maxAddrFailuresBeforeRotate. Addresses are tried in the given order,
+// wrapping back to the primary address (the one passed to New /
+// NewFromReference) after the last fallback. It has no effect on
+// NewWithClient, whose *consulapi.Client is used as-is.
+func WithFallbackAddresses(addresses []string) Option {
+	return func(p *provider) {
+		p.fallbackAddresses = addresses
+	}
+}
+
+// WithOnDelta registers a callback invoked after every successful
+// Update alongside WithOnUpdate, reporting which keys were added,
+// changed or removed relative to the previously applied snapshot,
+// instead of requiring callers to diff two full types.ParamValues
+// themselves. It is called from the worker goroutine and must not
+// block. A removed KeyRef means the key disappeared from Consul; see
+// also the explicit revert applied to such keys before Update is
+// called.
+func WithOnDelta(fn func(added, changed, removed []KeyRef)) Option {
+	return func(p *provider) {
+		p.onDelta = fn
+	}
+}
+
+// WithOnKeyModified registers a callback invoked whenever a watched
+// key's Consul ModifyIndex advances past the previously observed value
+// together with an actual value change, carrying the old and new
+// values and index/Flags metadata. Unlike WithOnDelta, which reports
+// after a poll's result has been pushed to the updater, this fires
+// during the poll itself, for every such key regardless of whether the
+// overall result changes enough to be applied - an audit/observability
+// hook for detecting a value changed outside the normal deployment
+// pipeline, not a substitute for the normal update flow.
+func WithOnKeyModified(fn func(KeyModifiedEvent)) Option {
+	return func(p *provider) {
+		p.onKeyModified = fn
+	}
+}
+
+// WithTracerProvider wraps each prefix's kv.List call in a span created
+// from tp, with attributes for the prefix, datacenter, resulting index
+// and result count, and records the error on the span when the call
+// fails. The default of nil creates no spans and adds no tracing
+// overhead.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(p *provider) {
+		p.tracerProvider = tp
+	}
+}
+
+// WithExcludePrefixes skips any key whose path, after trimming the
+// watched prefix, starts with one of prefixes - e.g. "secrets/" to
+// leave a sub-tree handled by a different mechanism out of this
+// provider entirely. Excluded keys are skipped before the set/param
+// split, the same as any other ignored key, saving the processing they'd
+// otherwise cost. Only keys read by a prefix listing are checked; keys
+// given to WithPerKeyWatch are not filtered by it.
+func WithExcludePrefixes(prefixes []string) Option {
+	return func(p *provider) {
+		p.excludePrefixes = prefixes
+	}
+}
+
+// WithValueDecoder runs decode on every value read from Consul before
+// it is turned into a parameter value, e.g. to transparently unpack a
+// compressed blob. Use GzipValueDecoder for gzip-compressed values.
+//
+// A value that fails to decode is reported via WithOnIgnoredKey and
+// skipped, the same as a key with too many segments, rather than
+// failing the whole poll.
+func WithValueDecoder(decode func([]byte) ([]byte, error)) Option {
+	return func(p *provider) {
+		p.valueDecoder = decode
+	}
+}
+
+// WithValueCodec is WithValueDecoder under the name this feature tends
+// to get requested by when the use case is application-layer encryption
+// rather than compression: decode is applied to every pair.Value before
+// it becomes a parameter string, and a value that fails to decode -
+// e.g. because it can't be decrypted with the configured key - is
+// skipped via WithOnIgnoredKey rather than failing the whole poll. The
+// two options set the same underlying field; use whichever name reads
+// better at the call site.
+func WithValueCodec(decode func([]byte) ([]byte, error)) Option {
+	return WithValueDecoder(decode)
+}
@@ -0,0 +1,40 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithWaitTime(t *testing.T) {
+	p := &provider{}
+	WithWaitTime(5 * time.Second)(p)
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.WaitTime != 5*time.Second {
+		t.Fatalf("WaitTime = %s, want 5s", opts.WaitTime)
+	}
+}
+
+func TestWithWaitTimeDefault(t *testing.T) {
+	p := &provider{}
+
+	opts := p.queryOptions(context.Background(), 0)
+	if opts.WaitTime != slowPoolInterval {
+		t.Fatalf("WaitTime = %s, want %s", opts.WaitTime, slowPoolInterval)
+	}
+}
+
+func TestWithWaitTimeRejectsNonPositive(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("WithWaitTime(%s) did not panic", d)
+				}
+			}()
+
+			WithWaitTime(d)
+		}()
+	}
+}
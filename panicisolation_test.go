@@ -0,0 +1,54 @@
+package consul
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListRecoversFromPanicInKeyTransformForOneKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/bad/key", Value: []byte("boom")},
+		{Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	var ignored []string
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.onIgnoredKey = func(fullKey, reason string) { ignored = append(ignored, fullKey) }
+	p.keyTransform = func(rawKey string) (setName, paramName string, ok bool) {
+		if rawKey == "bad/key" {
+			panic("simulated malformed key")
+		}
+
+		setName, paramName, _ = strings.Cut(rawKey, "/")
+		return setName, paramName, true
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatalf("list() err = %v, want nil - a single bad key must not fail the whole poll", err)
+	}
+
+	if got := ret["db/host"]; got != "localhost" {
+		t.Errorf("db/host = %q, want localhost", got)
+	}
+
+	if got := ret["db/port"]; got != "5432" {
+		t.Errorf("db/port = %q, want 5432", got)
+	}
+
+	found := false
+	for _, k := range ignored {
+		if k == "app/bad/key" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("ignored keys = %v, want app/bad/key reported via WithOnIgnoredKey", ignored)
+	}
+}
@@ -0,0 +1,97 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// unixSocketAddr is the placeholder Config.Address used for a
+// unix-socket connection. Its value is never actually dialed - the
+// transport's DialContext always connects to the real socket path
+// instead - it only needs to be a syntactically valid host so the
+// client can build request URLs.
+const unixSocketAddr = "unix-socket"
+
+// buildConfig translates the provider's connection parameters into a
+// consul.Config for the given resolved address. Zero-valued parameters
+// must not change consul.NewClient's default behavior.
+func (r *provider) buildConfig(rawAddr string) (*consulapi.Config, error) {
+	addr, scheme, err := parseConsulAddr(rawAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &consulapi.Config{Address: addr}
+
+	var transport http.RoundTripper
+
+	switch scheme {
+	case "unix":
+		if _, statErr := os.Stat(addr); statErr != nil {
+			return nil, fmt.Errorf("consul: unix socket %q is not accessible: %w", addr, statErr)
+		}
+
+		unixTransport := cleanhttp.DefaultPooledTransport()
+		unixTransport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}
+
+		transport = unixTransport
+		cfg.Address = unixSocketAddr
+		cfg.Scheme = "http"
+	case "":
+	default:
+		cfg.Scheme = scheme
+	}
+
+	if r.token != "" {
+		cfg.Token = r.token
+	}
+
+	if r.tls != (TLSOptions{}) {
+		cfg.TLSConfig = consulapi.TLSConfig{
+			CAFile:             r.tls.CAFile,
+			CertFile:           r.tls.CertFile,
+			KeyFile:            r.tls.KeyFile,
+			InsecureSkipVerify: r.tls.InsecureSkipVerify,
+		}
+	}
+
+	if transport == nil && r.transportTuning != nil {
+		transport = buildTransport(*r.transportTuning)
+	}
+
+	if r.httpAuth != nil {
+		cfg.HttpAuth = r.httpAuth
+	}
+
+	if len(r.httpHeaders) > 0 {
+		next := transport
+		if next == nil {
+			next = cleanhttp.DefaultPooledTransport()
+		}
+
+		transport = &headerRoundTripper{headers: r.httpHeaders, next: next}
+	}
+
+	if transport != nil {
+		cfg.Transport = transport
+	}
+
+	if r.httpTimeout > 0 {
+		cfg.HttpClient = &http.Client{
+			Timeout:   r.httpTimeout,
+			Transport: cfg.Transport,
+		}
+	}
+
+	return cfg, nil
+}
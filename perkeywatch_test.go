@@ -0,0 +1,83 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListPerKeyWatchUsesGet(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/other/unused", Value: []byte("noise")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.perKeyWatchKeys = []string{"db/host"}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+
+	if _, ok := ret["other/unused"]; ok {
+		t.Fatal("per-key watch must not surface keys outside the watched set")
+	}
+}
+
+func TestListPerKeyWatchMissingKeyIsIgnored(t *testing.T) {
+	kv := &fakeKV{}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.perKeyWatchKeys = []string{"db/host"}
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != 0 {
+		t.Fatalf("ret = %v, want empty", ret)
+	}
+
+	if len(ignored) != 1 || ignored[0] != "app/db/host: watched key not found" {
+		t.Fatalf("ignored = %v", ignored)
+	}
+}
+
+func TestListPerKeyWatchFallsBackToListWhenTooManyKeys(t *testing.T) {
+	pairs := make(consulapi.KVPairs, 0, maxPerKeyWatchKeys+1)
+	known := map[string]bool{}
+	keys := make([]string, 0, maxPerKeyWatchKeys+1)
+
+	for i := 0; i < maxPerKeyWatchKeys+1; i++ {
+		name := fmt.Sprintf("param%d", i)
+		pairs = append(pairs, &consulapi.KVPair{Key: "app/db/" + name, Value: []byte("v")})
+		known["db/"+name] = true
+		keys = append(keys, "db/"+name)
+	}
+
+	kv := &fakeKV{pairs: pairs}
+	p := newTestProvider(kv, known)
+	p.perKeyWatchKeys = keys
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != len(keys) {
+		t.Fatalf("ret has %d entries, want %d (fallback to List)", len(ret), len(keys))
+	}
+}
@@ -0,0 +1,25 @@
+package consul
+
+import "time"
+
+// PollWakeInfo describes why a single blocking-query poll of one
+// watched prefix (or, under WithPerKeyWatch, one watched key) returned:
+// because Consul's index actually advanced, or because the call simply
+// timed out with no change. See WithOnPollWake.
+type PollWakeInfo struct {
+	// Prefix is the watched prefix, or "<prefix><set>/<param>" key
+	// under WithPerKeyWatch, this poll was for.
+	Prefix string
+	// Changed reports whether NewIndex differs from PreviousIndex.
+	Changed bool
+	// PreviousIndex is the WaitIndex the query blocked on.
+	PreviousIndex uint64
+	// NewIndex is meta.LastIndex returned by this poll.
+	NewIndex uint64
+	// IndexDelta is NewIndex-PreviousIndex, negative when the index
+	// was reset (see nextWaitIndex).
+	IndexDelta int64
+	// RequestTime is meta.RequestTime, how long the Consul server took
+	// to answer, including any time spent blocked waiting for a change.
+	RequestTime time.Duration
+}
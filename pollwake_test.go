@@ -0,0 +1,104 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestOnPollWakeReportsTimeoutOnlyWake(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		lastIndex: 5,
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.protected.waitIx["app/"] = 5
+
+	var got PollWakeInfo
+
+	p.onPollWake = func(info PollWakeInfo) {
+		got = info
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Changed {
+		t.Fatalf("PollWakeInfo.Changed = true, want false for a timeout-only wake")
+	}
+
+	if got.PreviousIndex != 5 || got.NewIndex != 5 || got.IndexDelta != 0 {
+		t.Fatalf("PollWakeInfo = %+v, want PreviousIndex=NewIndex=5, IndexDelta=0", got)
+	}
+}
+
+func TestOnPollWakeReportsIndexAdvance(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		lastIndex: 9,
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.protected.waitIx["app/"] = 3
+
+	var got PollWakeInfo
+
+	p.onPollWake = func(info PollWakeInfo) {
+		got = info
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Changed {
+		t.Fatal("PollWakeInfo.Changed = false, want true when the index advanced")
+	}
+
+	if got.PreviousIndex != 3 || got.NewIndex != 9 || got.IndexDelta != 6 {
+		t.Fatalf("PollWakeInfo = %+v, want PreviousIndex=3, NewIndex=9, IndexDelta=6", got)
+	}
+}
+
+func TestOnPollWakeReceivesRequestTime(t *testing.T) {
+	kv := &fakeKVWithRequestTime{
+		fakeKV:      fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}}},
+		requestTime: 250 * time.Millisecond,
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	var got PollWakeInfo
+
+	p.onPollWake = func(info PollWakeInfo) {
+		got = info
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.RequestTime != 250*time.Millisecond {
+		t.Fatalf("RequestTime = %s, want 250ms", got.RequestTime)
+	}
+}
+
+// fakeKVWithRequestTime wraps fakeKV to also populate
+// QueryMeta.RequestTime, which the plain fakeKV leaves zero.
+type fakeKVWithRequestTime struct {
+	fakeKV
+	requestTime time.Duration
+}
+
+func (f *fakeKVWithRequestTime) List(prefix string, q *consulapi.QueryOptions) (consulapi.KVPairs, *consulapi.QueryMeta, error) {
+	pairs, meta, err := f.fakeKV.List(prefix, q)
+	if meta != nil {
+		meta.RequestTime = f.requestTime
+	}
+
+	return pairs, meta, err
+}
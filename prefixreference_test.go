@@ -0,0 +1,36 @@
+package consul
+
+import "testing"
+
+func TestResolvePrefixOverridesConstructorPrefix(t *testing.T) {
+	p := &provider{prefixFn: func() string { return "env/" }}
+	p.protected.prefix = "app/"
+
+	p.resolvePrefix()
+
+	if got := p.currentPrefix(); got != "env/" {
+		t.Fatalf("prefix = %q, want %q", got, "env/")
+	}
+}
+
+func TestResolvePrefixFallsBackWhenReferenceResolvesEmpty(t *testing.T) {
+	p := &provider{prefixFn: func() string { return "" }}
+	p.protected.prefix = "app/"
+
+	p.resolvePrefix()
+
+	if got := p.currentPrefix(); got != "app/" {
+		t.Fatalf("prefix = %q, want fallback %q", got, "app/")
+	}
+}
+
+func TestResolvePrefixNoopWithoutReference(t *testing.T) {
+	p := &provider{}
+	p.protected.prefix = "app/"
+
+	p.resolvePrefix()
+
+	if got := p.currentPrefix(); got != "app/" {
+		t.Fatalf("prefix = %q, want unchanged %q", got, "app/")
+	}
+}
@@ -0,0 +1,32 @@
+package consul
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validatePrefixSeparator returns a descriptive error if the configured
+// key separator conflicts with one of the watched prefixes - most
+// commonly WithKeySeparator set to a character that also appears
+// inside a WithPrefixes entry. splitKey only trims the matched prefix
+// before splitting on the separator, so a separator character left
+// over inside the prefix itself doesn't affect splitting directly, but
+// it reliably means the operator confused the separator with a path
+// segment delimiter, which is worth catching before Watch starts
+// polling instead of producing parameter names nobody expects.
+func (r *provider) validatePrefixSeparator() error {
+	sep := r.keySeparatorOrDefault()
+	if sep == "/" {
+		// The default separator; prefixes are always "/"-delimited
+		// paths, so this is never a conflict.
+		return nil
+	}
+
+	for _, prefix := range r.prefixes() {
+		if strings.Contains(prefix, sep) {
+			return fmt.Errorf("consul: prefix %q contains the configured key separator %q; choose a WithKeySeparator that doesn't appear in a watched prefix, or the derived parameter names won't be what you expect", prefix, sep)
+		}
+	}
+
+	return nil
+}
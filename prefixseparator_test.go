@@ -0,0 +1,56 @@
+package consul
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePrefixSeparatorRejectsSeparatorInPrefix(t *testing.T) {
+	p := newTestProvider(&fakeKV{}, nil)
+	p.protected.prefix = "app.cfg/"
+	p.keySeparator = "."
+
+	err := p.validatePrefixSeparator()
+	if err == nil {
+		t.Fatal("validatePrefixSeparator() err = nil, want an error for a separator that appears in the prefix")
+	}
+}
+
+func TestValidatePrefixSeparatorRejectsSeparatorInAdditionalPrefix(t *testing.T) {
+	p := newTestProvider(&fakeKV{}, nil)
+	p.protected.prefix = "app/"
+	p.additionalPrefixes = []string{"other.cfg/"}
+	p.keySeparator = "."
+
+	if err := p.validatePrefixSeparator(); err == nil {
+		t.Fatal("validatePrefixSeparator() err = nil, want an error for an additional prefix containing the separator")
+	}
+}
+
+func TestValidatePrefixSeparatorAcceptsNonConflictingConfig(t *testing.T) {
+	p := newTestProvider(&fakeKV{}, nil)
+	p.protected.prefix = "app/"
+	p.keySeparator = "."
+
+	if err := p.validatePrefixSeparator(); err != nil {
+		t.Fatalf("validatePrefixSeparator() err = %v, want nil", err)
+	}
+}
+
+func TestValidatePrefixSeparatorAcceptsDefaultSeparator(t *testing.T) {
+	p := newTestProvider(&fakeKV{}, nil)
+	p.protected.prefix = "app/db/"
+
+	if err := p.validatePrefixSeparator(); err != nil {
+		t.Fatalf("validatePrefixSeparator() err = %v, want nil for the default \"/\" separator", err)
+	}
+}
+
+func TestWatchRejectsConflictingPrefixAndSeparator(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app.cfg/", WithKeySeparator(".")).(*provider)
+
+	err := p.Watch(&countingUpdater{})
+	if err == nil || !strings.Contains(err.Error(), "key separator") {
+		t.Fatalf("Watch() err = %v, want an error about the conflicting key separator", err)
+	}
+}
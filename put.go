@@ -0,0 +1,30 @@
+package consul
+
+import (
+	"context"
+	"errors"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// ErrWriteAccessDisabled is returned by Put when the provider wasn't
+// constructed with WithWriteAccess.
+var ErrWriteAccessDisabled = errors.New("consul: write access is disabled, construct the provider with WithWriteAccess")
+
+// Put writes value to the key composed from the provider's prefix and
+// setName/paramName. It is separate from the read-only watch path and
+// must be explicitly enabled with WithWriteAccess, so the common
+// read-only usage can't accidentally write to Consul.
+func (r *provider) Put(ctx context.Context, setName, paramName, value string) error {
+	if !r.writable {
+		return ErrWriteAccessDisabled
+	}
+
+	key := r.currentPrefix() + setName + "/" + paramName
+
+	opts := &consulapi.WriteOptions{Datacenter: r.datacenter, Namespace: r.namespace}
+
+	_, err := r.currentKV().Put(&consulapi.KVPair{Key: key, Value: []byte(value)}, opts.WithContext(ctx))
+
+	return err
+}
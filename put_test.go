@@ -0,0 +1,35 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPutDisabledByDefault(t *testing.T) {
+	p := &provider{kv: &fakeKV{}}
+	p.protected.prefix = "app/"
+
+	if err := p.Put(context.Background(), "db", "host", "localhost"); !errors.Is(err, ErrWriteAccessDisabled) {
+		t.Fatalf("err = %v, want ErrWriteAccessDisabled", err)
+	}
+}
+
+func TestPutWritesValue(t *testing.T) {
+	kv := &fakeKV{}
+	p := &provider{kv: kv, writable: true}
+	p.protected.prefix = "app/"
+
+	if err := p.Put(context.Background(), "db", "host", "localhost"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _, err := kv.Get("app/db/host", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got == nil || string(got.Value) != "localhost" {
+		t.Fatalf("stored pair = %v, want value localhost", got)
+	}
+}
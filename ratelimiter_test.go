@@ -0,0 +1,80 @@
+package consul
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"golang.org/x/time/rate"
+)
+
+func TestListRateLimiterSpacesOutRequests(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	p := &provider{
+		kv:                 kv,
+		paramNames:         stubParamNames{known: map[string]bool{"db/host": true, "db/port": true}},
+		additionalPrefixes: []string{"other/"},
+		rateLimiter:        rate.NewLimiter(rate.Every(50*time.Millisecond), 1),
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	start := time.Now()
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	elapsed := time.Since(start)
+	if elapsed < 40*time.Millisecond {
+		t.Fatalf("list() across 2 prefixes took %s, want it spaced out by the rate limiter", elapsed)
+	}
+}
+
+func TestListRateLimiterHonorsContextCancellation(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := &provider{
+		kv:          kv,
+		paramNames:  stubParamNames{known: map[string]bool{"db/host": true}},
+		rateLimiter: rate.NewLimiter(rate.Every(time.Hour), 1),
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	// Drain the single burst token so the next Wait must actually block
+	// on the limiter instead of succeeding immediately.
+	_ = p.rateLimiter.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := p.list(ctx); err == nil {
+		t.Fatal("list() err = nil, want the cancelled context to abort the rate limiter wait")
+	}
+}
+
+func TestListNoRateLimiterIssuesRequestsImmediately(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	start := time.Now()
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("list() took %s without a rate limiter configured, want it to return immediately", elapsed)
+	}
+}
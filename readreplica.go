@@ -0,0 +1,15 @@
+package consul
+
+// readBackend returns the kvBackend Get and Keys should use: the
+// WithReadReplica client if one was configured, otherwise the same
+// client the watch loop uses. r.readKV is only ever set once, by
+// Watch before the worker starts, so it's safe to read directly; the
+// primary client can be swapped concurrently by rotateAddress or a
+// token refresh, so it goes through currentKV instead of r.kv.
+func (r *provider) readBackend() kvBackend {
+	if r.readKV != nil {
+		return r.readKV
+	}
+
+	return r.currentKV()
+}
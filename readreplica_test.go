@@ -0,0 +1,67 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestGetUsesReadReplicaWhenConfigured(t *testing.T) {
+	primary := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("primary")}}}
+	replica := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("replica")}}}
+
+	p := newTestProvider(primary, nil)
+	p.readKV = replica
+
+	value, ok, err := p.Get(context.Background(), "db", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || value != "replica" {
+		t.Fatalf("Get() = (%q, %v), want (\"replica\", true) from the read replica", value, ok)
+	}
+}
+
+func TestKeysUsesReadReplicaWhenConfigured(t *testing.T) {
+	primary := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("primary")}}}
+	replica := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/other/key", Value: []byte("replica")}}}
+
+	p := newTestProvider(primary, nil)
+	p.readKV = replica
+
+	keys, err := p.Keys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || keys[0] != "other/key" {
+		t.Fatalf("Keys() = %v, want [\"other/key\"] from the read replica", keys)
+	}
+}
+
+func TestGetFallsBackToPrimaryWithoutReadReplica(t *testing.T) {
+	primary := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("primary")}}}
+
+	p := newTestProvider(primary, nil)
+
+	value, ok, err := p.Get(context.Background(), "db", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || value != "primary" {
+		t.Fatalf("Get() = (%q, %v), want (\"primary\", true)", value, ok)
+	}
+}
+
+func TestWithReadReplicaSetsAddr(t *testing.T) {
+	p := &provider{}
+
+	WithReadReplica("http://127.0.0.1:8501")(p)
+
+	if p.readReplicaAddr != "http://127.0.0.1:8501" {
+		t.Fatalf("readReplicaAddr = %q, want %q", p.readReplicaAddr, "http://127.0.0.1:8501")
+	}
+}
@@ -0,0 +1,28 @@
+package consul
+
+import "strings"
+
+// nonRecursive backs WithRecursive: recursive listing is the default
+// (matching kv.List's own behavior), so the field tracks the opt-out
+// rather than the (more common) opt-in, letting the zero value of
+// provider keep the default behavior.
+//
+// recursiveEnabled reports whether keys deeper than the watched
+// prefix's immediate children are turned into parameters. It does not
+// change what's requested from Consul - see WithRecursive.
+func (r *provider) recursiveEnabled() bool {
+	return !r.nonRecursive
+}
+
+// isBeyondImmediateChild reports whether k, a key already trimmed of
+// its prefix, has more than one "/"-separated (or
+// WithKeySeparator-separated) segment, i.e. it lives deeper than the
+// prefix's direct children. In non-recursive mode such keys are
+// skipped silently, the same as a dot-prefixed key, rather than
+// reported through WithOnIgnoredKey - the whole point of
+// WithRecursive(false) is to not turn that part of the subtree into
+// parameters, even though the subtree is still fetched every poll; see
+// WithRecursive.
+func (r *provider) isBeyondImmediateChild(k string) bool {
+	return strings.Contains(k, r.keySeparatorOrDefault())
+}
@@ -0,0 +1,77 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func nestedTreeKV() *fakeKV {
+	return &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "infra/database/host", Value: []byte("db.internal")},
+		{Key: "infra/database/port", Value: []byte("5432")},
+		{Key: "infra/database/replicas/0/host", Value: []byte("replica0.internal")},
+	}}
+}
+
+func TestListRecursiveDefaultIncludesDeepKeys(t *testing.T) {
+	p := newTestProvider(nestedTreeKV(), map[string]bool{"db/host": true, "db/port": true})
+	p.setPrefixes = []setPrefixMapping{{setName: "db", prefix: "infra/database/"}}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "db.internal" || ret["db/port"] != "5432" {
+		t.Fatalf("ret = %+v, want db/host and db/port populated", ret)
+	}
+}
+
+func TestListNonRecursiveSkipsDeepKeysWithoutReportingThem(t *testing.T) {
+	var ignored []string
+	p := newTestProvider(nestedTreeKV(), map[string]bool{"db/host": true, "db/port": true})
+	p.setPrefixes = []setPrefixMapping{{setName: "db", prefix: "infra/database/"}}
+	p.nonRecursive = true
+	p.onIgnoredKey = func(fullKey, reason string) { ignored = append(ignored, fullKey) }
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "db.internal" || ret["db/port"] != "5432" {
+		t.Fatalf("ret = %+v, want the two immediate children still populated", ret)
+	}
+
+	if len(ret) != 2 {
+		t.Fatalf("ret = %+v, want the nested replica key excluded", ret)
+	}
+
+	if len(ignored) != 0 {
+		t.Fatalf("ignored = %v, want the nested key skipped silently, not reported", ignored)
+	}
+}
+
+func TestWithRecursiveFalseSetsNonRecursive(t *testing.T) {
+	p := &provider{}
+	WithRecursive(false)(p)
+
+	if !p.nonRecursive {
+		t.Fatal("nonRecursive = false, want true after WithRecursive(false)")
+	}
+
+	if p.recursiveEnabled() {
+		t.Fatal("recursiveEnabled() = true, want false after WithRecursive(false)")
+	}
+}
+
+func TestWithRecursiveTrueKeepsDefault(t *testing.T) {
+	p := &provider{}
+	WithRecursive(true)(p)
+
+	if p.nonRecursive {
+		t.Fatal("nonRecursive = true, want false after WithRecursive(true)")
+	}
+}
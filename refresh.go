@@ -0,0 +1,45 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+)
+
+// Refresh forces an immediate list of the watched prefixes and, if the
+// result differs from the last applied value, pushes it to the updater
+// right away instead of waiting for the next blocking-query poll or
+// debounce deadline. It is safe to call concurrently with the running
+// update worker and with itself: the list is performed on the calling
+// goroutine, and applying the result is serialized against the worker's
+// own poll via updateMu.
+//
+// Refresh returns an error if Watch has not been called yet, if the
+// list fails, or if the updater rejects the refreshed values.
+func (r *provider) Refresh(ctx context.Context) error {
+	r.protected.mutex.Lock()
+	started := r.protected.started
+	r.protected.mutex.Unlock()
+
+	if !started {
+		return fmt.Errorf("consul: Refresh called before Watch")
+	}
+
+	ret, err := r.list(ctx)
+	if err != nil {
+		return fmt.Errorf("consul: Refresh: %w", err)
+	}
+
+	r.protected.mutex.Lock()
+	ret = withExplicitDeletions(r.protected.lastApplied, ret)
+	r.protected.mutex.Unlock()
+
+	if err := r.applyListResult(ret); err != nil {
+		r.protected.mutex.Lock()
+		r.protected.lastErr = err
+		r.protected.mutex.Unlock()
+
+		return fmt.Errorf("consul: Refresh: %w", err)
+	}
+
+	return nil
+}
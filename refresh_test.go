@@ -0,0 +1,69 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestRefreshPushesImmediateUpdate(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("newhost")},
+	}}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+	p.protected.started = true
+	p.protected.lastApplied = types.ParamValues{"db/host": "oldhost"}
+
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := updater.callCount(); got != 1 {
+		t.Fatalf("Update called %d times, want 1", got)
+	}
+
+	if p.protected.lastApplied["db/host"] != "newhost" {
+		t.Fatalf("lastApplied = %v, want db/host=newhost", p.protected.lastApplied)
+	}
+}
+
+func TestRefreshSkipsUpdateWhenUnchanged(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+	updater := &countingUpdater{}
+	p := &provider{
+		kv:         kv,
+		updater:    updater,
+		paramNames: stubParamNames{known: map[string]bool{"db/host": true}},
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+	p.protected.started = true
+	p.protected.lastApplied = types.ParamValues{"db/host": "localhost"}
+
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := updater.callCount(); got != 0 {
+		t.Fatalf("Update called %d times for an unchanged refresh, want 0", got)
+	}
+}
+
+func TestRefreshBeforeWatchReturnsError(t *testing.T) {
+	p := &provider{}
+
+	if err := p.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh() err = nil, want an error before Watch has run")
+	}
+}
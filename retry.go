@@ -0,0 +1,60 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultListRetries    = 2
+	defaultListRetryDelay = 200 * time.Millisecond
+)
+
+// listRetryAttempts returns the number of retries list performs for a
+// retryable error, replacing the default of defaultListRetries.
+func (r *provider) listRetryAttempts() int {
+	if r.listRetries > 0 {
+		return r.listRetries
+	}
+
+	return defaultListRetries
+}
+
+// listRetryDelay returns the delay list waits between retries,
+// replacing the default of defaultListRetryDelay.
+func (r *provider) listRetryDelay() time.Duration {
+	if r.retryDelay > 0 {
+		return r.retryDelay
+	}
+
+	return defaultListRetryDelay
+}
+
+var consulStatusCodeRe = regexp.MustCompile(`Unexpected response code: (\d+)`)
+
+// isRetryableErr reports whether err looks like a transient failure -
+// a temporary/timeout network error, or an HTTP 5xx from Consul -
+// worth retrying inside list. A permanent failure, like the 403 an ACL
+// denial produces, is returned unchanged: retrying immediately
+// wouldn't fix it.
+func isRetryableErr(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary() //nolint:staticcheck // Temporary is deprecated but still the signal consul/api's http errors implement
+	}
+
+	if m := consulStatusCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		code, convErr := strconv.Atoi(m[1])
+		return convErr == nil && code >= 500
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+type fakeTimeoutErr struct{}
+
+func (fakeTimeoutErr) Error() string   { return "i/o timeout" }
+func (fakeTimeoutErr) Timeout() bool   { return true }
+func (fakeTimeoutErr) Temporary() bool { return true }
+
+var _ net.Error = fakeTimeoutErr{}
+
+func TestIsRetryableErrNetworkTimeout(t *testing.T) {
+	if !isRetryableErr(fakeTimeoutErr{}) {
+		t.Fatal("want a network timeout to be retryable")
+	}
+}
+
+func TestIsRetryableErrHTTP5xx(t *testing.T) {
+	if !isRetryableErr(errors.New("Unexpected response code: 503 (service unavailable)")) {
+		t.Fatal("want an HTTP 503 to be retryable")
+	}
+}
+
+func TestIsRetryableErrHTTP4xxIsNot(t *testing.T) {
+	if isRetryableErr(errors.New("Unexpected response code: 403 (Permission denied)")) {
+		t.Fatal("want an HTTP 403 to not be retryable")
+	}
+}
+
+func TestListRetriesTransientErrorThenSucceeds(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		failCount: 2,
+		failErr:   errors.New("Unexpected response code: 500 (internal error)"),
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.listRetries = 3
+	p.retryDelay = time.Hour
+	p.clock = newFakeClock(time.Now())
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListGivesUpAfterExhaustingRetries(t *testing.T) {
+	kv := &fakeKV{
+		failCount: 10,
+		failErr:   errors.New("Unexpected response code: 500 (internal error)"),
+	}
+
+	p := newTestProvider(kv, nil)
+	p.listRetries = 2
+	p.retryDelay = time.Hour
+	p.clock = newFakeClock(time.Now())
+
+	if _, err := p.list(context.Background()); err == nil {
+		t.Fatal("list() err = nil, want error after exhausting retries")
+	}
+}
+
+func TestListDoesNotRetryNonRetryableError(t *testing.T) {
+	kv := &fakeKV{
+		failCount: 1,
+		failErr:   errors.New("Unexpected response code: 403 (Permission denied)"),
+	}
+
+	p := newTestProvider(kv, nil)
+	p.listRetries = 5
+	p.retryDelay = time.Hour
+	p.clock = newFakeClock(time.Now())
+
+	if _, err := p.list(context.Background()); err == nil {
+		t.Fatal("list() err = nil, want the non-retryable error returned immediately")
+	}
+
+	if kv.failCount != 0 {
+		t.Fatalf("failCount = %d, want 0 (only one attempt should have been made)", kv.failCount)
+	}
+}
+
+func TestListRetryDelayGoesThroughInjectableClock(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		failCount: 1,
+		failErr:   errors.New("Unexpected response code: 500 (internal error)"),
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.listRetries = 1
+	// A real, un-faked sleep of this length would fail the test
+	// suite's own timeout long before it elapsed - retry-loop backoff
+	// must go through r.sleep/r.clockOrDefault instead of a bare
+	// time.Sleep for a fake clock to have any effect on it.
+	p.retryDelay = time.Hour
+	p.clock = newFakeClock(time.Now())
+
+	start := time.Now()
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("list() took %s, want the fake clock to make the hour-long retryDelay resolve instantly", elapsed)
+	}
+}
@@ -0,0 +1,67 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+// SeedDefaults writes each set/param in values to the KV key composed
+// from prefix, for bootstrapping a new environment with a starting set
+// of configuration. A key that already exists in Consul is left
+// untouched unless overwrite is true, so the common use of running it
+// against an environment that's already been touched by hand doesn't
+// clobber those changes.
+//
+// This is an admin-side complement to the read-only provider: nothing
+// returned by New or NewFromReference calls it, and it doesn't require
+// WithWriteAccess since it operates on a plain *consulapi.Client rather
+// than a provider.
+func SeedDefaults(ctx context.Context, client *consulapi.Client, prefix string, values types.ParamValues, overwrite bool) error {
+	return seedDefaults(ctx, client.KV(), prefix, values, overwrite)
+}
+
+func seedDefaults(ctx context.Context, kv kvBackend, prefix string, values types.ParamValues, overwrite bool) error {
+	prefix = normalizePrefix(prefix)
+
+	for key, value := range values {
+		setName, paramName, ok := splitParamValuesKey(key)
+		if !ok {
+			return fmt.Errorf("consul: SeedDefaults: %q is not a valid set/param key", key)
+		}
+
+		fullKey := prefix + setName + "/" + paramName
+
+		if !overwrite {
+			existing, _, err := kv.Get(fullKey, (&consulapi.QueryOptions{}).WithContext(ctx))
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				continue
+			}
+		}
+
+		_, err := kv.Put(&consulapi.KVPair{Key: fullKey, Value: []byte(value)}, (&consulapi.WriteOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitParamValuesKey splits a types.ParamValues key of the "<set>/<param>"
+// shape produced by list() back into its two components.
+func splitParamValuesKey(key string) (setName, paramName string, ok bool) {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return "", "", false
+	}
+
+	return key[:i], key[i+1:], true
+}
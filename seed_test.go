@@ -0,0 +1,77 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestSeedDefaultsWritesMissingKeys(t *testing.T) {
+	kv := &fakeKV{}
+
+	err := seedDefaults(context.Background(), kv, "app", types.ParamValues{
+		"db/host": "localhost",
+		"db/port": "5432",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"app/db/host": "localhost", "app/db/port": "5432"}
+	if len(kv.pairs) != len(want) {
+		t.Fatalf("got %d pairs, want %d", len(kv.pairs), len(want))
+	}
+
+	for _, pair := range kv.pairs {
+		if string(pair.Value) != want[pair.Key] {
+			t.Fatalf("key %q = %q, want %q", pair.Key, pair.Value, want[pair.Key])
+		}
+	}
+}
+
+func TestSeedDefaultsSkipsExistingKeysWithoutOverwrite(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("prod-host")},
+	}}
+
+	err := seedDefaults(context.Background(), kv, "app", types.ParamValues{
+		"db/host": "localhost",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(kv.pairs[0].Value); got != "prod-host" {
+		t.Fatalf("existing key was overwritten, got %q", got)
+	}
+}
+
+func TestSeedDefaultsOverwritesWhenRequested(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("prod-host")},
+	}}
+
+	err := seedDefaults(context.Background(), kv, "app", types.ParamValues{
+		"db/host": "localhost",
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(kv.pairs[0].Value); got != "localhost" {
+		t.Fatalf("key was not overwritten, got %q", got)
+	}
+}
+
+func TestSeedDefaultsRejectsMalformedKey(t *testing.T) {
+	kv := &fakeKV{}
+
+	err := seedDefaults(context.Background(), kv, "app", types.ParamValues{
+		"no-slash": "value",
+	}, false)
+	if err == nil {
+		t.Fatal("expected an error for a key without a set/param separator")
+	}
+}
@@ -0,0 +1,84 @@
+package consul
+
+import "strings"
+
+// agentBackend abstracts the subset of *consulapi.Agent used to query
+// the connected server's version, so tests can substitute a stub agent
+// endpoint instead of a live Consul.
+type agentBackend interface {
+	Self() (map[string]map[string]interface{}, error)
+}
+
+// reportServerVersion queries the connected agent's /v1/agent/self once
+// during Watch, logging and recording the server's version so operators
+// can tell at a glance which Consul release a deployment is talking to.
+//
+// Namespaces and partitions are Consul Enterprise-only features; if
+// either is configured against what looks like an OSS server (a
+// version string with no "+ent" build metadata), a warning is logged so
+// the misconfiguration is visible immediately instead of namespace/
+// partition silently having no effect.
+//
+// A failure to query /v1/agent/self is only logged, not returned: it's
+// a diagnostics nice-to-have, not something that should keep Watch from
+// starting.
+func (r *provider) reportServerVersion() {
+	if r.agent == nil {
+		return
+	}
+
+	self, err := r.agent.Self()
+	if err != nil {
+		r.logInfo("consul: WARNING failed to query agent self for server version",
+			String("error", err.Error()))
+		return
+	}
+
+	version := serverVersionFromSelf(self)
+
+	r.protected.mutex.Lock()
+	r.protected.serverVersion = version
+	r.protected.mutex.Unlock()
+
+	if version == "" {
+		return
+	}
+
+	r.logInfo("consul: connected to Consul server", String("version", version))
+
+	if (r.namespace != "" || r.partition != "") && !isEnterpriseVersion(version) {
+		r.logInfo("consul: WARNING namespace/partition configured against a server that does not look like Consul Enterprise",
+			String("version", version))
+	}
+}
+
+// serverVersionFromSelf extracts the "Version" field from the "Config"
+// section of an agent /v1/agent/self response, returning "" if it's
+// missing or not a string.
+func serverVersionFromSelf(self map[string]map[string]interface{}) string {
+	cfg, ok := self["Config"]
+	if !ok {
+		return ""
+	}
+
+	version, _ := cfg["Version"].(string)
+
+	return version
+}
+
+// isEnterpriseVersion reports whether a Consul version string looks
+// like an Enterprise build, which append "+ent" build metadata, e.g.
+// "1.17.0+ent".
+func isEnterpriseVersion(version string) bool {
+	return strings.Contains(version, "+ent")
+}
+
+// ServerVersion returns the Consul server version discovered by Watch's
+// initial /v1/agent/self query, or "" before Watch has completed that
+// query, or if it failed.
+func (r *provider) ServerVersion() string {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return r.protected.serverVersion
+}
@@ -0,0 +1,96 @@
+package consul
+
+import "testing"
+
+// fakeAgent is an in-memory agentBackend used by unit tests so they
+// don't need a running Consul agent to stub /v1/agent/self.
+type fakeAgent struct {
+	self map[string]map[string]interface{}
+	err  error
+}
+
+func (a *fakeAgent) Self() (map[string]map[string]interface{}, error) {
+	return a.self, a.err
+}
+
+func TestReportServerVersionLogsAndStoresVersion(t *testing.T) {
+	p := &provider{agent: &fakeAgent{self: map[string]map[string]interface{}{
+		"Config": {"Version": "1.17.0"},
+	}}}
+	logger := &capturingLogger{}
+	p.logger = logger
+
+	p.reportServerVersion()
+
+	if got := p.ServerVersion(); got != "1.17.0" {
+		t.Fatalf("ServerVersion() = %q, want %q", got, "1.17.0")
+	}
+
+	found := false
+	for _, msg := range logger.infoMsgs {
+		if msg == "consul: connected to Consul server" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("infoMsgs = %v, want a message announcing the server version", logger.infoMsgs)
+	}
+}
+
+func TestReportServerVersionWarnsOnNamespaceAgainstOSSServer(t *testing.T) {
+	p := &provider{agent: &fakeAgent{self: map[string]map[string]interface{}{
+		"Config": {"Version": "1.17.0"},
+	}}, namespace: "team-a"}
+	logger := &capturingLogger{}
+	p.logger = logger
+
+	p.reportServerVersion()
+
+	found := false
+	for _, msg := range logger.infoMsgs {
+		if msg == "consul: WARNING namespace/partition configured against a server that does not look like Consul Enterprise" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("infoMsgs = %v, want an Enterprise mismatch warning", logger.infoMsgs)
+	}
+}
+
+func TestReportServerVersionNoWarningOnEnterpriseServer(t *testing.T) {
+	p := &provider{agent: &fakeAgent{self: map[string]map[string]interface{}{
+		"Config": {"Version": "1.17.0+ent"},
+	}}, partition: "billing"}
+	logger := &capturingLogger{}
+	p.logger = logger
+
+	p.reportServerVersion()
+
+	for _, msg := range logger.infoMsgs {
+		if msg == "consul: WARNING namespace/partition configured against a server that does not look like Consul Enterprise" {
+			t.Fatalf("infoMsgs = %v, want no Enterprise mismatch warning against an Enterprise server", logger.infoMsgs)
+		}
+	}
+}
+
+func TestReportServerVersionHandlesAgentError(t *testing.T) {
+	p := &provider{agent: &fakeAgent{err: errListFailed}}
+	logger := &capturingLogger{}
+	p.logger = logger
+
+	p.reportServerVersion()
+
+	if got := p.ServerVersion(); got != "" {
+		t.Fatalf("ServerVersion() = %q, want empty after a failed query", got)
+	}
+}
+
+func TestServerVersionEmptyBeforeQuery(t *testing.T) {
+	p := &provider{}
+
+	if got := p.ServerVersion(); got != "" {
+		t.Fatalf("ServerVersion() = %q, want empty before Watch queries it", got)
+	}
+}
@@ -0,0 +1,30 @@
+package consul
+
+// currentPrefix returns the prefix currently being watched, guarded by
+// protected.mutex so it can be safely read while SetPrefix is changing
+// it concurrently from another goroutine.
+func (r *provider) currentPrefix() string {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return r.protected.prefix
+}
+
+// SetPrefix changes the KV prefix a running provider watches, after
+// normalizing it the same way New does. It resets the blocking-query
+// wait index to 0, so the worker's next poll of the new prefix returns
+// immediately with the current state instead of blocking for up to
+// WaitTime, the same way a WaitIndex decrease is already handled by
+// nextWaitIndex.
+//
+// It is safe to call at any time, including while Watch's background
+// worker is running; the change takes effect on the worker's next
+// iteration.
+func (r *provider) SetPrefix(prefix string) {
+	prefix = normalizePrefix(prefix)
+
+	r.protected.mutex.Lock()
+	r.protected.prefix = prefix
+	r.protected.waitIx = make(map[string]uint64)
+	r.protected.mutex.Unlock()
+}
@@ -0,0 +1,51 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestSetPrefixSwitchesToNewPrefixValues(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "old/db/host", Value: []byte("old-host")},
+		{Key: "new/db/host", Value: []byte("new-host")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.protected.prefix = "old/"
+	p.protected.waitIx["old/"] = 42
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "old-host" {
+		t.Fatalf("ret = %v, want db/host=old-host", ret)
+	}
+
+	p.SetPrefix("new")
+
+	if got := p.currentPrefix(); got != "new/" {
+		t.Fatalf("currentPrefix() = %q, want %q", got, "new/")
+	}
+
+	p.protected.mutex.Lock()
+	_, stillHasOld := p.protected.waitIx["old/"]
+	p.protected.mutex.Unlock()
+
+	if stillHasOld {
+		t.Fatal("waitIx for the old prefix was not reset by SetPrefix")
+	}
+
+	ret, err = p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "new-host" {
+		t.Fatalf("ret = %v, want db/host=new-host after SetPrefix", ret)
+	}
+}
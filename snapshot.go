@@ -0,0 +1,26 @@
+package consul
+
+import (
+	"time"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// Snapshot returns a copy of the parameter values applied by the most
+// recent successful update, together with when that update happened.
+// It's meant for introspection - e.g. a "/debug/config" endpoint asking
+// "is my config stale?" - and is safe to call concurrently with the
+// worker. The returned map is a deep copy, so callers can't
+// accidentally mutate provider state or race with the worker updating
+// it.
+func (r *provider) Snapshot() (types.ParamValues, time.Time) {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	snapshot := make(types.ParamValues, len(r.protected.lastApplied))
+	for k, v := range r.protected.lastApplied {
+		snapshot[k] = v
+	}
+
+	return snapshot, r.protected.lastUpdateTime
+}
@@ -0,0 +1,77 @@
+package consul
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+func TestSnapshotReturnsLastApplied(t *testing.T) {
+	p := &provider{}
+	now := time.Now()
+
+	p.protected.lastApplied = types.ParamValues{"db/host": "localhost"}
+	p.protected.lastUpdateTime = now
+
+	values, at := p.Snapshot()
+	if values["db/host"] != "localhost" {
+		t.Fatalf("values = %v, want db/host=localhost", values)
+	}
+
+	if !at.Equal(now) {
+		t.Fatalf("at = %v, want %v", at, now)
+	}
+}
+
+func TestSnapshotReturnsDeepCopy(t *testing.T) {
+	p := &provider{}
+	p.protected.lastApplied = types.ParamValues{"db/host": "localhost"}
+
+	values, _ := p.Snapshot()
+	values["db/host"] = "mutated"
+
+	if p.protected.lastApplied["db/host"] != "localhost" {
+		t.Fatal("mutating the returned snapshot must not affect provider state")
+	}
+}
+
+func TestSnapshotConcurrentWithWorker(t *testing.T) {
+	p := &provider{}
+	p.protected.lastApplied = types.ParamValues{}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	stop := make(chan struct{})
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			p.protected.mutex.Lock()
+			p.protected.lastApplied = types.ParamValues{"db/host": "localhost"}
+			p.protected.lastUpdateTime = time.Now()
+			p.protected.mutex.Unlock()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			p.Snapshot()
+		}
+
+		close(stop)
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,33 @@
+package consul
+
+import "time"
+
+// Status summarizes the provider's connection health as observed by
+// the update worker.
+type Status struct {
+	Healthy        bool
+	LastError      error
+	LastUpdateTime time.Time
+}
+
+// Healthy reports whether the most recent poll against Consul
+// succeeded. It is safe to call concurrently with the running worker.
+func (r *provider) Healthy() bool {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return r.protected.lastErr == nil
+}
+
+// Status returns the last error observed by the worker (nil if the
+// last poll succeeded) and the time of the last successful update.
+func (r *provider) Status() Status {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return Status{
+		Healthy:        r.protected.lastErr == nil,
+		LastError:      r.protected.lastErr,
+		LastUpdateTime: r.protected.lastUpdateTime,
+	}
+}
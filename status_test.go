@@ -0,0 +1,27 @@
+package consul
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHealthyDefault(t *testing.T) {
+	p := &provider{}
+	if !p.Healthy() {
+		t.Fatal("Healthy() = false, want true before any poll")
+	}
+}
+
+func TestHealthyReflectsLastError(t *testing.T) {
+	p := &provider{}
+	p.protected.lastErr = errors.New("boom")
+
+	if p.Healthy() {
+		t.Fatal("Healthy() = true, want false after an error")
+	}
+
+	status := p.Status()
+	if status.Healthy || status.LastError == nil {
+		t.Fatalf("Status() = %+v, want Healthy=false with LastError set", status)
+	}
+}
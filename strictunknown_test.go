@@ -0,0 +1,60 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestWatchRejectsUnknownKeyWhenStrict(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/hots", Value: []byte("localhost")},
+	}}
+
+	p := &provider{
+		kv:                kv,
+		paramNames:        stubParamNames{known: map[string]bool{"db/host": true}},
+		strictUnknownKeys: true,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p.protected.mutex.Lock()
+	unknown := p.protected.unknownKeys
+	p.protected.mutex.Unlock()
+
+	if len(unknown) != 1 || unknown[0] != "app/db/hots" {
+		t.Fatalf("unknownKeys = %v, want [app/db/hots]", unknown)
+	}
+}
+
+func TestWatchAcceptsOnlyKnownKeysWhenStrict(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := &provider{
+		kv:                kv,
+		paramNames:        stubParamNames{known: map[string]bool{"db/host": true}},
+		strictUnknownKeys: true,
+	}
+	p.protected.prefix = "app/"
+	p.protected.waitIx = make(map[string]uint64)
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p.protected.mutex.Lock()
+	unknown := p.protected.unknownKeys
+	p.protected.mutex.Unlock()
+
+	if len(unknown) != 0 {
+		t.Fatalf("unknownKeys = %v, want none", unknown)
+	}
+}
@@ -0,0 +1,98 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListStripsLeadingSegmentsBeforeSplit(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "env/prod/myapp/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.protected.prefix = "env/prod/myapp/"
+	p.stripSegments = 0
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListStripSegmentsSkipsFixedSegment(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "env/eu-west-1/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.protected.prefix = "env/"
+	p.stripSegments = 1
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListStripSegmentsIgnoresKeyWithTooFewSegments(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "env/eu-west-1", Value: []byte("x")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{})
+	p.protected.prefix = "env/"
+	p.stripSegments = 1
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ignored) != 1 {
+		t.Fatalf("ignored = %v, want 1 entry", ignored)
+	}
+}
+
+func TestListStripSegmentsMultiple(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "env/prod/eu-west-1/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.protected.prefix = "env/"
+	p.stripSegments = 2
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestWithStripSegmentsPanicsOnNegativeN(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WithStripSegments(-1) did not panic")
+		}
+	}()
+
+	WithStripSegments(-1)
+}
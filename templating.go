@@ -0,0 +1,62 @@
+package consul
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// renderTemplates renders every value in ret containing "{{" as a Go
+// text/template, with the full raw snapshot available as the
+// template's data via {{index . "set/param"}}, so a value like a
+// connection string can reference sibling parameters gathered in the
+// same poll. Templates see the raw, pre-rendering snapshot, not each
+// other's rendered output, so there's no ordering or cycle to worry
+// about between two templated values.
+//
+// A value without "{{" is left untouched. A template that fails to
+// parse or execute (e.g. it references a key that doesn't exist) is
+// logged and its raw, unrendered text is kept instead - a broken
+// template shouldn't drop the whole snapshot or crash the worker.
+func (r *provider) renderTemplates(ret types.ParamValues) {
+	if !r.valueTemplating {
+		return
+	}
+
+	raw := make(types.ParamValues, len(ret))
+	for k, v := range ret {
+		raw[k] = v
+	}
+
+	for key, value := range ret {
+		if !strings.Contains(value, "{{") {
+			continue
+		}
+
+		rendered, err := renderTemplate(key, value, raw)
+		if err != nil {
+			r.logInfo("consul: WARNING failed to render value template, using raw value",
+				String("key", key), String("error", err.Error()))
+
+			continue
+		}
+
+		ret[key] = rendered
+	}
+}
+
+func renderTemplate(key, value string, data types.ParamValues) (string, error) {
+	tmpl, err := template.New(key).Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,81 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListRendersTemplateReferencingSiblingKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+		{Key: "app/db/port", Value: []byte("5432")},
+		{Key: "app/db/dsn", Value: []byte(`postgres://{{index . "db/host"}}:{{index . "db/port"}}/mydb`)},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true, "db/dsn": true})
+	p.valueTemplating = true
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "postgres://localhost:5432/mydb"; ret["db/dsn"] != want {
+		t.Fatalf("db/dsn = %q, want %q", ret["db/dsn"], want)
+	}
+}
+
+func TestListLeavesPlainValuesUntouchedWhenTemplatingEnabled(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.valueTemplating = true
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("db/host = %q, want unchanged %q", ret["db/host"], "localhost")
+	}
+}
+
+func TestListFallsBackToRawValueOnTemplateError(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/dsn", Value: []byte(`{{ .Broken`)},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/dsn": true})
+	p.valueTemplating = true
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "{{ .Broken"; ret["db/dsn"] != want {
+		t.Fatalf("db/dsn = %q, want raw fallback %q", ret["db/dsn"], want)
+	}
+}
+
+func TestListDoesNotTemplateWithoutOption(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/dsn", Value: []byte(`{{index . "db/host"}}`)},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/dsn": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{{index . "db/host"}}`; ret["db/dsn"] != want {
+		t.Fatalf("db/dsn = %q, want raw value left untouched by default", ret["db/dsn"])
+	}
+}
@@ -0,0 +1,112 @@
+package consul
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// isACLError reports whether err looks like a Consul ACL/permission
+// denial, which retrying with the same token will never fix - it needs
+// a new token (see TokenProvider/WithTokenFile) instead. It first
+// checks the HTTP status code Consul returned (403), falling back to
+// matching known ACL error messages for responses that don't carry a
+// status code in the expected shape.
+func isACLError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if m := consulStatusCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		if code, convErr := strconv.Atoi(m[1]); convErr == nil && code == 403 {
+			return true
+		}
+	}
+
+	return strings.Contains(err.Error(), "Permission denied") || strings.Contains(err.Error(), "ACL not found")
+}
+
+// readTokenFile reads and trims the ACL token from path. The read is
+// a plain os.ReadFile call so it never holds protected.mutex.
+func readTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// refreshToken re-obtains the ACL token, from whichever rotation
+// mechanism is configured, and rebuilds the Consul client if it
+// changed. It is called whenever a poll fails with an ACL/permission
+// error, since retrying with the same token would only fail again.
+func (r *provider) refreshToken(ctx context.Context) error {
+	switch {
+	case r.tokenProvider != nil:
+		token, err := r.tokenProvider.Token(ctx)
+		if err != nil {
+			return err
+		}
+
+		return r.applyNewToken(token)
+	case r.tokenFile != "":
+		token, err := readTokenFile(r.tokenFile)
+		if err != nil {
+			return err
+		}
+
+		return r.applyNewToken(token)
+	default:
+		return nil
+	}
+}
+
+// applyNewToken rebuilds the Consul client with token if it differs
+// from the one currently in use, reassigning every backend field the
+// same way rotateAddress does, and leaves the client untouched
+// otherwise. It reconnects via activeAddress rather than always
+// resolving back to the original primary, so a token refresh (which
+// can be triggered by an ACL error while a fallback address is
+// already active) doesn't undo a rotateAddress failover. The swap
+// happens under protected.mutex, since r.client and the backend
+// fields are read concurrently by Get, Put, Keys and AcquireLeadership
+// from arbitrary caller goroutines - see rotateAddress for the same
+// requirement.
+func (r *provider) applyNewToken(token string) error {
+	if token == r.token {
+		return nil
+	}
+
+	r.token = token
+
+	addr, err := r.activeAddress()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := r.buildConfig(addr)
+	if err != nil {
+		return err
+	}
+
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	r.protected.mutex.Lock()
+	r.client = client
+	r.kv = r.client.KV()
+	r.session = r.client.Session()
+	r.lockKV = r.client.KV()
+	r.txnKV = r.client.KV()
+	r.catalog = r.client.Catalog()
+	r.agent = r.client.Agent()
+	r.protected.mutex.Unlock()
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadTokenFileTrimsWhitespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := readTokenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "s3cr3t" {
+		t.Fatalf("token = %q, want %q", token, "s3cr3t")
+	}
+}
+
+func TestRefreshTokenFromFilePicksUpRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &provider{
+		tokenFile: path,
+		token:     "old",
+		consulURLFn: func() (string, error) {
+			return "http://127.0.0.1:8500", nil
+		},
+	}
+
+	if err := os.WriteFile(path, []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.refreshToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.token != "new" {
+		t.Fatalf("token = %q, want %q", p.token, "new")
+	}
+}
+
+func TestRefreshTokenViaTokenProvider(t *testing.T) {
+	p := &provider{
+		tokenProvider: StaticToken("new"),
+		token:         "old",
+		consulURLFn: func() (string, error) {
+			return "http://127.0.0.1:8500", nil
+		},
+	}
+
+	if err := p.refreshToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.token != "new" {
+		t.Fatalf("token = %q, want %q", p.token, "new")
+	}
+}
+
+func TestRefreshTokenProviderTakesPrecedenceOverTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &provider{
+		tokenFile:     path,
+		tokenProvider: StaticToken("from-provider"),
+		token:         "old",
+		consulURLFn: func() (string, error) {
+			return "http://127.0.0.1:8500", nil
+		},
+	}
+
+	if err := p.refreshToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.token != "from-provider" {
+		t.Fatalf("token = %q, want %q", p.token, "from-provider")
+	}
+}
+
+func TestIsACLError(t *testing.T) {
+	if isACLError(nil) {
+		t.Fatal("isACLError(nil) = true")
+	}
+}
+
+func TestIsACLErrorByStatusCode(t *testing.T) {
+	if !isACLError(errors.New("Unexpected response code: 403 (Permission denied)")) {
+		t.Fatal("want a 403 status code to classify as an ACL error")
+	}
+}
+
+func TestIsACLErrorNotFor5xx(t *testing.T) {
+	if isACLError(errors.New("Unexpected response code: 500 (internal error)")) {
+		t.Fatal("want a 500 to not classify as an ACL error")
+	}
+}
+
+func TestIsACLErrorByMessageFallback(t *testing.T) {
+	if !isACLError(errors.New("ACL not found")) {
+		t.Fatal("want an \"ACL not found\" message to classify as an ACL error")
+	}
+}
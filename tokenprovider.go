@@ -0,0 +1,24 @@
+package consul
+
+import "context"
+
+// TokenProvider supplies the Consul ACL token used to authenticate
+// requests. It is consulted whenever a poll fails with an ACL error,
+// before the client is rebuilt, so short-lived tokens (e.g. issued and
+// renewed by Vault's Consul secrets engine) can be refreshed without
+// hardcoding credentials or restarting the process. Implementations
+// must be safe for concurrent use.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenProvider that always returns the same fixed
+// token. It's mainly useful for tests and for callers who don't need
+// rotation; production use with rotating credentials should implement
+// TokenProvider against the actual secret store instead.
+type StaticToken string
+
+// Token implements TokenProvider.
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
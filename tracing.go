@@ -0,0 +1,49 @@
+package consul
+
+import (
+	"context"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by this package to whatever
+// backend the configured TracerProvider exports to.
+const tracerName = "github.com/simplesurance/proteus-consul"
+
+// startListSpan starts a span around a single prefix's kv.List call
+// when WithTracerProvider is configured, returning a function that
+// records the outcome and must be called once the call returns. When no
+// tracer is configured, finish is a no-op and no span is created, so
+// there is no tracing overhead.
+func (r *provider) startListSpan(prefix string) (finish func(pairs consulapi.KVPairs, index uint64, err error)) {
+	if r.tracerProvider == nil {
+		return func(consulapi.KVPairs, uint64, error) {}
+	}
+
+	baseCtx := r.baseCtx
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+
+	_, span := r.tracerProvider.Tracer(tracerName).Start(baseCtx, "consul.kv.List", trace.WithAttributes(
+		attribute.String("consul.prefix", prefix),
+		attribute.String("consul.datacenter", r.datacenter),
+	))
+
+	return func(pairs consulapi.KVPairs, index uint64, err error) {
+		span.SetAttributes(
+			attribute.Int64("consul.index", int64(index)),
+			attribute.Int("consul.result_count", len(pairs)),
+		)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}
+}
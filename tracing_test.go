@@ -0,0 +1,87 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestListCreatesSpanWhenTracerProviderConfigured(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.tracerProvider = tp
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "consul.kv.List" {
+		t.Fatalf("span name = %q, want %q", span.Name, "consul.kv.List")
+	}
+
+	attrs := make(map[string]string)
+	for _, kv := range span.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	if attrs["consul.prefix"] != "app/" {
+		t.Fatalf("attrs = %v, want consul.prefix=app/", attrs)
+	}
+
+	if attrs["consul.result_count"] != "1" {
+		t.Fatalf("attrs = %v, want consul.result_count=1", attrs)
+	}
+}
+
+func TestListNoTracerProviderCreatesNoSpan(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	finish := p.startListSpan("app/")
+	finish(nil, 0, nil)
+}
+
+func TestListRecordsErrorOnSpan(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	kv := &fakeKV{listErr: errListFailed}
+
+	p := newTestProvider(kv, nil)
+	p.tracerProvider = tp
+
+	if _, err := p.list(context.Background()); err == nil {
+		t.Fatal("list() err = nil, want the fake KV's error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	if len(spans[0].Events) == 0 {
+		t.Fatal("span has no recorded error event")
+	}
+}
@@ -0,0 +1,86 @@
+package consul
+
+import (
+	"net/http"
+	"time"
+)
+
+// headerRoundTripper adds a fixed set of headers to every request before
+// delegating to next, so operators can satisfy a reverse proxy that
+// requires a custom header Consul itself knows nothing about.
+type headerRoundTripper struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (t *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// defaultMaxIdleConns, defaultMaxIdleConnsPerHost and
+// defaultIdleConnTimeout are buildTransport's defaults for a
+// WithTransportTuning field left at its zero value: a higher per-host
+// idle pool and a longer idle timeout than
+// cleanhttp.DefaultPooledTransport uses, since a provider repeatedly
+// long-polls the same single Consul address and benefits from keeping
+// that one connection warm rather than tearing it down between polls.
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 32
+	defaultIdleConnTimeout     = 5 * time.Minute
+)
+
+// TransportOptions tunes the HTTP transport used for the Consul
+// client's connections, for WithTransportTuning. The zero value for
+// any field uses the matching defaultXxx constant.
+type TransportOptions struct {
+	// MaxIdleConns caps the total number of idle (keep-alive)
+	// connections kept open across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost caps idle connections kept open per host.
+	// Since a provider only ever talks to one Consul address, this is
+	// the setting that actually governs connection reuse here.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open
+	// before being closed.
+	IdleConnTimeout time.Duration
+
+	// DisableHTTP2 turns off automatic HTTP/2 protocol negotiation,
+	// which net/http's transport otherwise attempts by default.
+	DisableHTTP2 bool
+}
+
+// buildTransport turns opts into an *http.Transport, filling in
+// defaultXxx for any zero-valued field.
+func buildTransport(opts TransportOptions) *http.Transport {
+	maxIdleConns := opts.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = defaultMaxIdleConns
+	}
+
+	maxIdleConnsPerHost := opts.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	idleConnTimeout := opts.IdleConnTimeout
+	if idleConnTimeout == 0 {
+		idleConnTimeout = defaultIdleConnTimeout
+	}
+
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        maxIdleConns,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+		ForceAttemptHTTP2:   !opts.DisableHTTP2,
+	}
+}
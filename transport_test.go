@@ -0,0 +1,119 @@
+package consul
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestBuildConfigHTTPBasicAuthAndHeaderReachRequest(t *testing.T) {
+	var gotUser, gotPass string
+	var gotOK bool
+	var gotHeader string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, gotOK = r.BasicAuth()
+		gotHeader = r.Header.Get("X-Proxy-Token")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	p := &provider{}
+	WithHTTPBasicAuth("alice", "s3cr3t")(p)
+	WithHTTPHeader("X-Proxy-Token", "abc123")(p)
+
+	cfg, err := p.buildConfig(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := consulapi.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := client.KV().List("app/", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if !gotOK || gotUser != "alice" || gotPass != "s3cr3t" {
+		t.Fatalf("BasicAuth = (%q, %q, %v), want (alice, s3cr3t, true)", gotUser, gotPass, gotOK)
+	}
+
+	if gotHeader != "abc123" {
+		t.Fatalf("X-Proxy-Token header = %q, want abc123", gotHeader)
+	}
+}
+
+func TestBuildTransportFillsDefaults(t *testing.T) {
+	tr := buildTransport(TransportOptions{})
+
+	if tr.MaxIdleConns != defaultMaxIdleConns {
+		t.Errorf("MaxIdleConns = %d, want %d", tr.MaxIdleConns, defaultMaxIdleConns)
+	}
+
+	if tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, want %d", tr.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost)
+	}
+
+	if tr.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %s, want %s", tr.IdleConnTimeout, defaultIdleConnTimeout)
+	}
+
+	if !tr.ForceAttemptHTTP2 {
+		t.Error("ForceAttemptHTTP2 = false, want true by default")
+	}
+}
+
+func TestBuildTransportHonorsExplicitFields(t *testing.T) {
+	tr := buildTransport(TransportOptions{
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     time.Second,
+		DisableHTTP2:        true,
+	})
+
+	if tr.MaxIdleConns != 10 || tr.MaxIdleConnsPerHost != 2 || tr.IdleConnTimeout != time.Second {
+		t.Errorf("tr = %+v, want the explicit fields honored", tr)
+	}
+
+	if tr.ForceAttemptHTTP2 {
+		t.Error("ForceAttemptHTTP2 = true, want false when DisableHTTP2 is set")
+	}
+}
+
+func TestBuildConfigAttachesTunedTransport(t *testing.T) {
+	p := &provider{transportTuning: &TransportOptions{MaxIdleConns: 42}}
+
+	cfg, err := p.buildConfig("http://127.0.0.1:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, ok := cfg.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", cfg.Transport)
+	}
+
+	if tr.MaxIdleConns != 42 {
+		t.Errorf("MaxIdleConns = %d, want 42", tr.MaxIdleConns)
+	}
+}
+
+func TestBuildConfigWithoutTransportTuningLeavesTransportNil(t *testing.T) {
+	p := &provider{}
+
+	cfg, err := p.buildConfig("http://127.0.0.1:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Transport != nil {
+		t.Errorf("Transport = %v, want nil without WithTransportTuning", cfg.Transport)
+	}
+}
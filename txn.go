@@ -0,0 +1,99 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/types"
+)
+
+// maxTxnOps is the largest number of operations Consul accepts in a
+// single transaction. Batches larger than this are split into several
+// transactions.
+const maxTxnOps = 64
+
+// kvTxnBackend is satisfied by *consulapi.KV.
+type kvTxnBackend interface {
+	Txn(txn consulapi.KVTxnOps, q *consulapi.QueryOptions) (bool, *consulapi.KVTxnResponse, *consulapi.QueryMeta, error)
+}
+
+// listPerKeyTxn reads every WithPerKeyWatch key with kv.Txn instead of
+// one blocking kv.Get per key. Every key in a batch is read at the same
+// Raft index, so the returned snapshot is consistent across keys -
+// unlike listPerKey, where each key is fetched with its own request and
+// can observe a different point in time.
+//
+// The Consul transaction endpoint has no blocking-query support, so
+// unlike listPerKey this never waits on WaitIndex/WaitTime: it always
+// performs a plain read and returns immediately. It is only worth using
+// alongside a poll-driving mechanism such as WithWaitTime treated as a
+// plain interval, or WithDebounce, rather than relying on it to block
+// until something changes.
+//
+// Consul also limits a single transaction to maxTxnOps operations, so
+// keys are read in batches when there are more of them than that; a
+// batch boundary means the keys on either side of it can be read at
+// different indexes, so the consistency guarantee only holds within a
+// batch.
+func (r *provider) listPerKeyTxn(ctx context.Context) (types.ParamValues, error) {
+	ret := types.ParamValues{}
+	modifyIndex := make(map[string]uint64)
+	var unknownKeys []string
+
+	for batchStart := 0; batchStart < len(r.perKeyWatchKeys); batchStart += maxTxnOps {
+		batchEnd := batchStart + maxTxnOps
+		if batchEnd > len(r.perKeyWatchKeys) {
+			batchEnd = len(r.perKeyWatchKeys)
+		}
+
+		batch := r.perKeyWatchKeys[batchStart:batchEnd]
+
+		if r.rateLimiter != nil {
+			if err := r.rateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		ops := make(consulapi.KVTxnOps, 0, len(batch))
+		fullKeys := make([]string, 0, len(batch))
+
+		for _, key := range batch {
+			fullKey := r.currentPrefix() + key
+			fullKeys = append(fullKeys, fullKey)
+			ops = append(ops, &consulapi.KVTxnOp{Verb: consulapi.KVGet, Key: fullKey})
+		}
+
+		start := time.Now()
+		_, resp, _, err := r.txnKV.Txn(ops, r.queryOptions(ctx, 0))
+		r.metrics.observePoll(start, err)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pairs := make(map[string]*consulapi.KVPair, len(resp.Results))
+		for _, result := range resp.Results {
+			if result.KV != nil {
+				pairs[result.KV.Key] = result.KV
+			}
+		}
+
+		for i, key := range batch {
+			setName, paramName, ok := r.splitKey(key)
+			if !ok {
+				return nil, fmt.Errorf("consul: WithPerKeyWatch key %q must have the same <set>/<param> shape as a regular Consul key", key)
+			}
+
+			r.applyPerKeyResult(setName, paramName, fullKeys[i], pairs[fullKeys[i]], ret, modifyIndex, &unknownKeys)
+		}
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.modifyIndex = modifyIndex
+	r.protected.unknownKeys = unknownKeys
+	r.protected.mutex.Unlock()
+
+	return ret, nil
+}
@@ -0,0 +1,140 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// fakeTxnKV is an in-memory kvTxnBackend used by unit tests so they
+// don't need a running Consul.
+type fakeTxnKV struct {
+	pairs   map[string]*consulapi.KVPair
+	txnErr  error
+	batches [][]string
+}
+
+func (f *fakeTxnKV) Txn(txn consulapi.KVTxnOps, _ *consulapi.QueryOptions) (bool, *consulapi.KVTxnResponse, *consulapi.QueryMeta, error) {
+	if f.txnErr != nil {
+		return false, nil, nil, f.txnErr
+	}
+
+	var keys []string
+	for _, op := range txn {
+		keys = append(keys, op.Key)
+	}
+	f.batches = append(f.batches, keys)
+
+	resp := &consulapi.KVTxnResponse{}
+
+	for _, op := range txn {
+		if pair, ok := f.pairs[op.Key]; ok {
+			resp.Results = append(resp.Results, &consulapi.TxnResult{KV: pair})
+		}
+	}
+
+	return true, resp, &consulapi.QueryMeta{LastIndex: 1}, nil
+}
+
+func TestListPerKeyTxnReadsAllKeysInOneTransaction(t *testing.T) {
+	txnKV := &fakeTxnKV{pairs: map[string]*consulapi.KVPair{
+		"app/db/host": {Key: "app/db/host", Value: []byte("localhost")},
+		"app/db/port": {Key: "app/db/port", Value: []byte("5432")},
+	}}
+
+	p := newTestProvider(nil, map[string]bool{"db/host": true, "db/port": true})
+	p.txnRead = true
+	p.txnKV = txnKV
+	p.perKeyWatchKeys = []string{"db/host", "db/port"}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" || ret["db/port"] != "5432" {
+		t.Fatalf("ret = %v, want db/host=localhost and db/port=5432", ret)
+	}
+
+	if len(txnKV.batches) != 1 || len(txnKV.batches[0]) != 2 {
+		t.Fatalf("batches = %v, want a single batch of 2 keys", txnKV.batches)
+	}
+}
+
+func TestListPerKeyTxnMissingKeyIsIgnored(t *testing.T) {
+	txnKV := &fakeTxnKV{pairs: map[string]*consulapi.KVPair{}}
+
+	p := newTestProvider(nil, map[string]bool{"db/host": true})
+	p.txnRead = true
+	p.txnKV = txnKV
+	p.perKeyWatchKeys = []string{"db/host"}
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != 0 {
+		t.Fatalf("ret = %v, want empty", ret)
+	}
+
+	if len(ignored) != 1 || ignored[0] != "app/db/host: watched key not found" {
+		t.Fatalf("ignored = %v", ignored)
+	}
+}
+
+func TestListPerKeyTxnBatchesLargeKeySets(t *testing.T) {
+	pairs := make(map[string]*consulapi.KVPair)
+	known := map[string]bool{}
+	keys := make([]string, 0, maxTxnOps+5)
+
+	for i := 0; i < maxTxnOps+5; i++ {
+		name := "param" + string(rune('a'+i%26)) + string(rune('0'+i/26))
+		key := "db/" + name
+		pairs["app/"+key] = &consulapi.KVPair{Key: "app/" + key, Value: []byte("v")}
+		known[key] = true
+		keys = append(keys, key)
+	}
+
+	txnKV := &fakeTxnKV{pairs: pairs}
+
+	p := newTestProvider(nil, known)
+	p.txnRead = true
+	p.txnKV = txnKV
+	// listPerKeyTxn is only reached through list() when the key count is
+	// within maxPerKeyWatchKeys, so call it directly to exercise
+	// batching across the larger key set on its own.
+	p.perKeyWatchKeys = keys
+
+	ret, err := p.listPerKeyTxn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ret) != len(keys) {
+		t.Fatalf("ret has %d entries, want %d", len(ret), len(keys))
+	}
+
+	if len(txnKV.batches) != 2 {
+		t.Fatalf("got %d transaction batches, want 2 (maxTxnOps=%d, keys=%d)", len(txnKV.batches), maxTxnOps, len(keys))
+	}
+}
+
+func TestListPerKeyTxnPropagatesTxnError(t *testing.T) {
+	txnKV := &fakeTxnKV{txnErr: errListFailed}
+
+	p := newTestProvider(nil, map[string]bool{"db/host": true})
+	p.txnRead = true
+	p.txnKV = txnKV
+	p.perKeyWatchKeys = []string{"db/host"}
+
+	if _, err := p.list(context.Background()); err == nil {
+		t.Fatal("list() err = nil, want the fake Txn's error")
+	}
+}
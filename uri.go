@@ -0,0 +1,51 @@
+package consul
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseConsulAddr splits a Consul HTTP API address into the
+// scheme/host shape consulapi.Config expects: Config.Address must not
+// include a scheme, and Config.Scheme carries it separately. A bare
+// "host:port" with no scheme is passed through as-is, matching
+// consulapi.Config's own default of "http".
+//
+// A "unix://" address is a special case: scheme is returned as "unix"
+// and address is the socket path (e.g. "unix:///var/run/consul.sock"
+// yields "/var/run/consul.sock"), for buildConfig to wire up a Unix
+// domain socket transport instead of a normal Scheme/Address pair.
+func parseConsulAddr(raw string) (address, scheme string, err error) {
+	if !strings.Contains(raw, "://") {
+		if raw == "" {
+			return "", "", fmt.Errorf("consul: address must not be empty")
+		}
+
+		return raw, "", nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("consul: malformed address %q: %w", raw, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+	case "unix":
+		sockPath := u.Host + u.Path
+		if sockPath == "" {
+			return "", "", fmt.Errorf("consul: address %q has no socket path", raw)
+		}
+
+		return sockPath, "unix", nil
+	default:
+		return "", "", fmt.Errorf("consul: unsupported scheme %q in address %q, want http, https or unix", u.Scheme, raw)
+	}
+
+	if u.Host == "" {
+		return "", "", fmt.Errorf("consul: address %q has no host", raw)
+	}
+
+	return u.Host, u.Scheme, nil
+}
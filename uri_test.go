@@ -0,0 +1,75 @@
+package consul
+
+import "testing"
+
+func TestParseConsulAddrHTTP(t *testing.T) {
+	addr, scheme, err := parseConsulAddr("http://consul:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr != "consul:8500" || scheme != "http" {
+		t.Fatalf("addr, scheme = %q, %q, want consul:8500, http", addr, scheme)
+	}
+}
+
+func TestParseConsulAddrHTTPS(t *testing.T) {
+	addr, scheme, err := parseConsulAddr("https://consul:8501")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr != "consul:8501" || scheme != "https" {
+		t.Fatalf("addr, scheme = %q, %q, want consul:8501, https", addr, scheme)
+	}
+}
+
+func TestParseConsulAddrBareHostPort(t *testing.T) {
+	addr, scheme, err := parseConsulAddr("consul:8500")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr != "consul:8500" || scheme != "" {
+		t.Fatalf("addr, scheme = %q, %q, want consul:8500, \"\"", addr, scheme)
+	}
+}
+
+func TestParseConsulAddrRejectsUnsupportedScheme(t *testing.T) {
+	if _, _, err := parseConsulAddr("ftp://consul:8500"); err == nil {
+		t.Fatal("err = nil, want an error for an unsupported scheme")
+	}
+}
+
+func TestParseConsulAddrRejectsMissingHost(t *testing.T) {
+	if _, _, err := parseConsulAddr("http://"); err == nil {
+		t.Fatal("err = nil, want an error for a missing host")
+	}
+}
+
+func TestParseConsulAddrRejectsEmpty(t *testing.T) {
+	if _, _, err := parseConsulAddr(""); err == nil {
+		t.Fatal("err = nil, want an error for an empty address")
+	}
+}
+
+func TestParseConsulAddrUnixSocket(t *testing.T) {
+	addr, scheme, err := parseConsulAddr("unix:///var/run/consul.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scheme != "unix" {
+		t.Fatalf("scheme = %q, want %q", scheme, "unix")
+	}
+
+	if addr != "/var/run/consul.sock" {
+		t.Fatalf("addr = %q, want %q", addr, "/var/run/consul.sock")
+	}
+}
+
+func TestParseConsulAddrRejectsUnixWithoutPath(t *testing.T) {
+	if _, _, err := parseConsulAddr("unix://"); err == nil {
+		t.Fatal("err = nil, want an error for a unix address with no socket path")
+	}
+}
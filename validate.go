@@ -0,0 +1,27 @@
+package consul
+
+import "context"
+
+// ValidatePresence lists the watched prefix once, independent of the
+// watch loop, and reports which of required (given as "<set>/<param>"
+// keys, the same shape used internally and by WithOnUpdate/Put) have no
+// corresponding value in Consul. It's meant to be called once at
+// startup so deployment mistakes (a required parameter with no Consul
+// key behind it) are caught before traffic hits, rather than surfacing
+// later as a missing config value.
+func (r *provider) ValidatePresence(ctx context.Context, required []string) ([]string, error) {
+	ret, err := r.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+
+	for _, key := range required {
+		if _, ok := ret[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,42 @@
+package consul
+
+import (
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestValidatePresenceReportsMissingKey(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+
+	missing, err := p.ValidatePresence(context.Background(), []string{"db/host", "db/port"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(missing) != 1 || missing[0] != "db/port" {
+		t.Fatalf("missing = %v, want [db/port]", missing)
+	}
+}
+
+func TestValidatePresenceAllPresent(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	missing, err := p.ValidatePresence(context.Background(), []string{"db/host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(missing) != 0 {
+		t.Fatalf("missing = %v, want none", missing)
+	}
+}
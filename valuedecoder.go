@@ -0,0 +1,21 @@
+package consul
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// GzipValueDecoder decompresses a gzip-compressed Consul value. Pass it
+// to WithValueDecoder for prefixes that store gzip-compressed blobs
+// instead of plain text.
+func GzipValueDecoder(value []byte) ([]byte, error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, fmt.Errorf("consul: not a valid gzip stream: %w", err)
+	}
+	defer gzr.Close()
+
+	return io.ReadAll(gzr)
+}
@@ -0,0 +1,91 @@
+package consul
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func gzipBytes(t *testing.T, plain string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gzw := gzip.NewWriter(&buf)
+	if _, err := gzw.Write([]byte(plain)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestListDecodesGzipValue(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: gzipBytes(t, "localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.valueDecoder = GzipValueDecoder
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestListSkipsValueThatFailsToDecode(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("not gzip")},
+		{Key: "app/db/port", Value: gzipBytes(t, "5432")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true, "db/port": true})
+	p.valueDecoder = GzipValueDecoder
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ret["db/host"]; ok {
+		t.Fatal("list() kept a value that failed to decode")
+	}
+
+	if ret["db/port"] != "5432" {
+		t.Fatalf("ret = %v, want db/port=5432", ret)
+	}
+}
+
+func TestListNoDecoderKeepsPlainValues(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ret["db/host"] != "localhost" {
+		t.Fatalf("ret = %v, want db/host=localhost", ret)
+	}
+}
+
+func TestGzipValueDecoderRejectsNonGzipInput(t *testing.T) {
+	if _, err := GzipValueDecoder([]byte("plain text")); err == nil {
+		t.Fatal("GzipValueDecoder err = nil, want an error for non-gzip input")
+	}
+}
@@ -0,0 +1,75 @@
+package consul
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithWaitTimeClampsAboveConsulMaximum(t *testing.T) {
+	p := &provider{}
+
+	WithWaitTime(20 * time.Minute)(p)
+
+	if p.waitTime != maxConsulWaitTime {
+		t.Fatalf("waitTime = %s, want it clamped to %s", p.waitTime, maxConsulWaitTime)
+	}
+}
+
+func TestWithWaitTimeLeavesInRangeValueUntouched(t *testing.T) {
+	p := &provider{}
+
+	WithWaitTime(2 * time.Minute)(p)
+
+	if p.waitTime != 2*time.Minute {
+		t.Fatalf("waitTime = %s, want 2m unclamped", p.waitTime)
+	}
+}
+
+func TestWithWaitTimePanicsOnNonPositiveDuration(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WithWaitTime(0) did not panic")
+		}
+	}()
+
+	WithWaitTime(0)
+}
+
+func TestConsulWaitPaddingIsWaitDividedBySixteen(t *testing.T) {
+	got := consulWaitPadding(160 * time.Second)
+	want := 10 * time.Second
+
+	if got != want {
+		t.Fatalf("consulWaitPadding(160s) = %s, want %s", got, want)
+	}
+}
+
+func TestWatchRejectsTimeoutWithinConsulPaddingOfWaitTime(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app/", WithWaitTime(16*time.Second)).(*provider)
+	// padding = 16s/16 = 1s, so a 16.5s timeout still leaves the
+	// worst-case padded response (17s) able to trip it.
+	p.httpTimeout = 16*time.Second + 500*time.Millisecond
+
+	if err := p.Watch(&countingUpdater{}); err == nil {
+		t.Fatal("Watch() err = nil, want an error for a timeout within Consul's padding window of WaitTime")
+	}
+}
+
+func TestWatchAcceptsTimeoutBeyondConsulPadding(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app/", WithWaitTime(16*time.Second)).(*provider)
+	p.httpTimeout = 18 * time.Second
+
+	// Watch() still fails here, since nothing is actually listening on
+	// 127.0.0.1:8500, but it must fail with a connection error from the
+	// initial list, not the httpTimeout/WaitTime validation error - that
+	// one only checks the boundary, not connectivity.
+	err := p.Watch(&countingUpdater{})
+	if err == nil {
+		t.Fatal("Watch() err = nil, want a connection error since nothing is listening")
+	}
+
+	if got := err.Error(); strings.Contains(got, "WithHTTPTimeout") {
+		t.Fatalf("Watch() err = %q, want a connection error, not the WithHTTPTimeout validation error", got)
+	}
+}
@@ -0,0 +1,519 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/simplesurance/proteus/sources"
+	"github.com/simplesurance/proteus/types"
+)
+
+// Watch connects to Consul, performs an initial synchronous list of the
+// watched prefix and then starts a background worker that keeps polling
+// Consul via blocking queries, pushing updates to updater until Stop is
+// called.
+func (r *provider) Watch(updater sources.Updater) error {
+	r.protected.mutex.Lock()
+	alreadyStarted := r.protected.started
+	r.protected.started = true
+	r.protected.mutex.Unlock()
+
+	if alreadyStarted {
+		return fmt.Errorf("consul: Watch was already called on this provider, construct a new one instead of calling Watch twice")
+	}
+
+	if r.consulURLFn != nil {
+		wait := r.effectiveWaitTime()
+		minTimeout := wait + consulWaitPadding(wait)
+
+		if r.httpTimeout > 0 && r.httpTimeout <= minTimeout {
+			return fmt.Errorf("consul: WithHTTPTimeout (%s) must be greater than the effective WaitTime plus Consul's random padding (%s), otherwise every blocking query would time out", r.httpTimeout, minTimeout)
+		}
+
+		addr, err := r.consulURLFn()
+		if err != nil {
+			return err
+		}
+
+		addr = r.envDefaultAddr(addr)
+
+		if r.namespaceRef != nil {
+			r.namespace = r.namespaceRef.Value()
+		}
+
+		if r.partitionRef != nil {
+			r.partition = r.partitionRef.Value()
+		}
+
+		r.resolvePrefix()
+
+		if r.tokenFile != "" {
+			token, err := readTokenFile(r.tokenFile)
+			if err != nil {
+				return err
+			}
+
+			r.token = token
+		}
+
+		r.token = r.envDefaultToken(r.token)
+
+		r.protected.mutex.Lock()
+		r.protected.addresses = append([]string{addr}, r.fallbackAddresses...)
+		r.protected.activeAddrIx = 0
+		r.protected.mutex.Unlock()
+
+		cfg, err := r.buildConfig(addr)
+		if err != nil {
+			return err
+		}
+
+		client, err := consulapi.NewClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		r.client = client
+	}
+
+	if !r.catalogMode {
+		if err := r.validatePrefixSeparator(); err != nil {
+			return err
+		}
+	}
+
+	r.kv = r.client.KV()
+	r.session = r.client.Session()
+	r.lockKV = r.client.KV()
+	r.txnKV = r.client.KV()
+	r.catalog = r.client.Catalog()
+	r.agent = r.client.Agent()
+	r.updater = updater
+	r.paramNames = updater.Parameters()
+
+	if r.readReplicaAddr != "" {
+		readCfg, err := r.buildConfig(r.readReplicaAddr)
+		if err != nil {
+			return err
+		}
+
+		readClient, err := consulapi.NewClient(readCfg)
+		if err != nil {
+			return err
+		}
+
+		r.readKV = readClient.KV()
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.waitIx = make(map[string]uint64)
+	r.protected.mutex.Unlock()
+
+	r.reportServerVersion()
+
+	baseCtx := r.baseCtx
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+
+	if r.startupJitter > 0 {
+		if err := sleep(baseCtx, randJitter(r.startupJitter)); err != nil {
+			return err
+		}
+	}
+
+	ret, err := r.initialList(baseCtx)
+	if err != nil {
+		if r.cacheFilePath == "" {
+			return err
+		}
+
+		cached, cacheErr := r.loadCache()
+		if cacheErr != nil {
+			return fmt.Errorf("consul: initial list failed (%s) and cache file fallback also failed: %w", err, cacheErr)
+		}
+
+		r.logDebug("consul: initial list failed, serving last-known values from cache file",
+			String("path", r.cacheFilePath),
+			String("error", err.Error()))
+
+		ret = cached
+	} else {
+		r.saveCache(ret)
+	}
+
+	r.checkEmptyPrefix()
+
+	if r.strictUnknownKeys {
+		r.protected.mutex.Lock()
+		unknown := r.protected.unknownKeys
+		r.protected.mutex.Unlock()
+
+		if len(unknown) > 0 {
+			return fmt.Errorf("consul: found %d key(s) under the watched prefix that match no known parameter: %v", len(unknown), unknown)
+		}
+	}
+
+	ret = r.initialUpdateValue(ret)
+
+	if err := r.updater.Update(ret); err != nil {
+		return err
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.lastApplied = ret
+	r.protected.mutex.Unlock()
+
+	ctx, cancel := context.WithCancel(baseCtx)
+
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	r.stopFn = cancel
+	r.stopped = &stopped
+
+	go r.updateWorker(ctx, &stopped)
+
+	return nil
+}
+
+// initialUpdateValue returns what Watch pushes to updater.Update right
+// after its initial list: ret itself, or an empty set when
+// WithDeferInitialApply is configured, in which case the update worker
+// delivers ret's values once it completes its own first poll, the same
+// way it delivers any other detected change.
+func (r *provider) initialUpdateValue(ret types.ParamValues) types.ParamValues {
+	if r.deferInitialApply {
+		return types.ParamValues{}
+	}
+
+	return ret
+}
+
+// resolvePrefix applies ParameterReferences.Prefix's resolved value
+// over the prefix given to NewFromReference, when a reference is
+// configured and resolves to a non-empty value. Otherwise the
+// constructor-supplied prefix is left untouched.
+func (r *provider) resolvePrefix() {
+	if r.prefixFn == nil {
+		return
+	}
+
+	if prefix := r.prefixFn(); prefix != "" {
+		r.protected.mutex.Lock()
+		r.protected.prefix = normalizePrefix(prefix)
+		r.protected.mutex.Unlock()
+	}
+}
+
+// initialList performs the first list called from Watch. When
+// startupTimeout is configured, transient failures are retried with
+// backoff until the deadline, returning the last error on timeout.
+// With the default of zero, it behaves like a single r.list() call.
+func (r *provider) initialList(ctx context.Context) (types.ParamValues, error) {
+	if r.startupTimeout <= 0 {
+		return r.list(ctx)
+	}
+
+	deadline := r.clockOrDefault().Now().Add(r.startupTimeout)
+	backoff := newBackoff(r.backoffBase, r.backoffMax)
+
+	for {
+		ret, err := r.list(ctx)
+		if err == nil {
+			r.protected.mutex.Lock()
+			r.protected.consecutiveFailures = 0
+			r.protected.maxFailuresNotified = false
+			r.protected.mutex.Unlock()
+
+			return ret, nil
+		}
+
+		r.protected.mutex.Lock()
+		r.protected.consecutiveFailures++
+		failures := r.protected.consecutiveFailures
+		r.protected.mutex.Unlock()
+
+		if failures >= maxAddrFailuresBeforeRotate {
+			_ = r.rotateAddress()
+		}
+
+		if r.clockOrDefault().Now().After(deadline) {
+			return nil, err
+		}
+
+		if sleepErr := r.sleep(ctx, backoff.next()); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// Stop cancels the background worker started by Watch and waits for it
+// to exit. Calling Stop before Watch (or when Watch returned an error
+// before starting the worker) is a safe no-op.
+func (r *provider) Stop() {
+	r.stopFn()
+
+	if r.stopped != nil {
+		r.stopped.Wait()
+	}
+}
+
+func (r *provider) updateWorker(ctx context.Context, stopped *sync.WaitGroup) {
+	defer stopped.Done()
+	defer r.recordWorkerExit()
+
+	backoff := newBackoff(r.backoffBase, r.backoffMax)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if r.updateWorkerIteration(ctx, backoff) {
+			return
+		}
+	}
+}
+
+// recordWorkerExit captures the last poll error as the worker's
+// terminal error, unless the worker is exiting cleanly (no error, or
+// its context was canceled by Stop) - see Err.
+func (r *provider) recordWorkerExit() {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	if r.protected.lastErr != nil && r.protected.lastErr != context.Canceled {
+		r.protected.workerErr = r.protected.lastErr
+	}
+}
+
+// Err returns the error the update worker was last seeing when it
+// stopped, or nil if it stopped cleanly. Call it after Stop returns;
+// while the worker is still running its eventual terminal error, if
+// any, isn't known yet.
+func (r *provider) Err() error {
+	r.protected.mutex.Lock()
+	defer r.protected.mutex.Unlock()
+
+	return r.protected.workerErr
+}
+
+// checkMaxConsecutiveFailures invokes onMaxConsecutiveFailures once
+// failures reaches the WithMaxConsecutiveFailures threshold, passing the
+// error from the failure that tripped it. It fires at most once per
+// outage: maxFailuresNotified guards against calling it again on every
+// further failed attempt until a successful poll resets the count.
+func (r *provider) checkMaxConsecutiveFailures(failures int, err error) {
+	if r.maxConsecutiveFailures <= 0 || failures < r.maxConsecutiveFailures {
+		return
+	}
+
+	r.protected.mutex.Lock()
+	alreadyNotified := r.protected.maxFailuresNotified
+	r.protected.maxFailuresNotified = true
+	r.protected.mutex.Unlock()
+
+	if alreadyNotified {
+		return
+	}
+
+	r.onMaxConsecutiveFailures(err)
+}
+
+// updateWorkerIteration runs one list+Update cycle of the worker loop.
+// It recovers from a panic in list or in the updater's Update, so a
+// single bad value can't permanently disable config reloads - the
+// panic is treated like any other poll error: recorded as lastErr and
+// followed by a backoff sleep before the next attempt. It reports
+// whether the worker loop must stop, which only happens on context
+// cancellation.
+func (r *provider) updateWorkerIteration(ctx context.Context, backoff *backoff) (stop bool) {
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+
+		r.protected.mutex.Lock()
+		r.protected.lastErr = fmt.Errorf("consul: recovered from panic in update worker: %v", rec)
+		r.protected.mutex.Unlock()
+
+		if r.sleep(ctx, backoff.next()) != nil {
+			stop = true
+		}
+	}()
+
+	ret, err := r.list(ctx)
+
+	r.protected.mutex.Lock()
+	r.protected.lastErr = err
+	r.protected.mutex.Unlock()
+
+	if err != nil {
+		if err == context.Canceled {
+			return true
+		}
+
+		r.protected.mutex.Lock()
+		r.protected.consecutiveFailures++
+		failures := r.protected.consecutiveFailures
+		r.protected.mutex.Unlock()
+
+		if failures >= maxAddrFailuresBeforeRotate {
+			if rotateErr := r.rotateAddress(); rotateErr != nil {
+				r.protected.mutex.Lock()
+				r.protected.lastErr = fmt.Errorf("%w (address rotation also failed: %s)", err, rotateErr)
+				r.protected.mutex.Unlock()
+			}
+		}
+
+		r.checkMaxConsecutiveFailures(failures, err)
+
+		if isACLError(err) {
+			aclErr := fmt.Errorf("consul: ACL/permission denied, current token is rejected: %w", err)
+
+			r.protected.mutex.Lock()
+			r.protected.lastErr = aclErr
+			r.protected.mutex.Unlock()
+
+			if refreshErr := r.refreshToken(ctx); refreshErr != nil {
+				r.protected.mutex.Lock()
+				r.protected.lastErr = fmt.Errorf("%w (token refresh also failed: %s)", aclErr, refreshErr)
+				r.protected.mutex.Unlock()
+			}
+		}
+
+		return r.sleep(ctx, backoff.next()) != nil
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.consecutiveFailures = 0
+	r.protected.maxFailuresNotified = false
+	r.protected.mutex.Unlock()
+
+	backoff.reset()
+
+	r.saveCache(ret)
+
+	r.protected.mutex.Lock()
+	ret = withExplicitDeletions(r.protected.lastApplied, ret)
+	r.protected.mutex.Unlock()
+
+	if r.debounce <= 0 {
+		return r.applyChange(ctx, backoff, ret)
+	}
+
+	return r.applyDebouncedChange(ctx, backoff, ret)
+}
+
+// applyChange delivers ret to the updater immediately, the behavior
+// used when WithDebounce is not configured.
+func (r *provider) applyChange(ctx context.Context, backoff *backoff, ret types.ParamValues) (stop bool) {
+	if err := r.applyListResult(ret); err != nil {
+		r.protected.mutex.Lock()
+		r.protected.lastErr = err
+		r.protected.mutex.Unlock()
+
+		return r.sleep(ctx, backoff.next()) != nil
+	}
+
+	return false
+}
+
+// applyListResult pushes ret to the updater if it differs from the last
+// applied value, updating bookkeeping and firing onUpdate/onDelta on
+// success. It holds updateMu for the duration of the call so that it
+// can't interleave with a concurrent call from Refresh.
+func (r *provider) applyListResult(ret types.ParamValues) error {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	r.protected.mutex.Lock()
+	prev := r.protected.lastApplied
+	unchanged := paramValuesEqual(ret, prev)
+	r.protected.mutex.Unlock()
+
+	if unchanged {
+		return nil
+	}
+
+	if err := r.updater.Update(ret); err != nil {
+		return err
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.lastUpdateTime = r.clockOrDefault().Now()
+	r.protected.lastApplied = ret
+	r.protected.mutex.Unlock()
+
+	if r.onUpdate != nil {
+		r.onUpdate(ret)
+	}
+
+	r.reportDelta(prev, ret)
+
+	return nil
+}
+
+// applyDebouncedChange implements WithDebounce: a ret that differs
+// from what's already applied replaces the pending snapshot and
+// (re)starts the debounce window instead of being applied right away.
+// The pending snapshot is only delivered to the updater once a poll
+// observes that the window has elapsed without a newer change
+// arriving - effectiveWaitTime caps the blocking query at the
+// remaining window so that poll happens promptly instead of only on
+// the next unrelated Consul write.
+func (r *provider) applyDebouncedChange(ctx context.Context, backoff *backoff, ret types.ParamValues) (stop bool) {
+	now := r.clockOrDefault().Now()
+
+	r.protected.mutex.Lock()
+
+	prev := r.protected.lastApplied
+
+	if !paramValuesEqual(ret, prev) && !paramValuesEqual(ret, r.protected.pendingUpdate) {
+		r.protected.pendingUpdate = ret
+		r.protected.pendingDeadline = now.Add(r.debounce)
+	}
+
+	pending := r.protected.pendingUpdate
+	deadline := r.protected.pendingDeadline
+	due := pending != nil && !deadline.IsZero() && !now.Before(deadline)
+
+	r.protected.mutex.Unlock()
+
+	if !due {
+		return false
+	}
+
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	if err := r.updater.Update(pending); err != nil {
+		r.protected.mutex.Lock()
+		r.protected.lastErr = err
+		r.protected.mutex.Unlock()
+
+		return r.sleep(ctx, backoff.next()) != nil
+	}
+
+	r.protected.mutex.Lock()
+	r.protected.lastUpdateTime = r.clockOrDefault().Now()
+	r.protected.lastApplied = pending
+	r.protected.pendingUpdate = nil
+	r.protected.pendingDeadline = time.Time{}
+	r.protected.mutex.Unlock()
+
+	if r.onUpdate != nil {
+		r.onUpdate(pending)
+	}
+
+	r.reportDelta(prev, pending)
+
+	return false
+}
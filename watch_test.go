@@ -0,0 +1,24 @@
+package consul
+
+import "testing"
+
+func TestWatchTwiceReturnsError(t *testing.T) {
+	p := &provider{}
+	p.protected.started = true
+
+	if err := p.Watch(&countingUpdater{}); err == nil {
+		t.Fatal("Watch() err = nil, want an error when Watch was already called on this provider")
+	}
+}
+
+func TestStopBeforeWatchIsSafe(t *testing.T) {
+	p := New("http://127.0.0.1:8500", "app/").(*provider)
+
+	p.Stop()
+}
+
+func TestStopBeforeWatchIsSafeFromReference(t *testing.T) {
+	p := NewFromReference(ParameterReferences{}, "app/").(*provider)
+
+	p.Stop()
+}
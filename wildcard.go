@@ -0,0 +1,56 @@
+package consul
+
+import (
+	"encoding/json"
+	"path"
+
+	"github.com/simplesurance/proteus/types"
+)
+
+// wildcardParam backs WithWildcardParam: paramNames under setName that
+// match pattern are collected into a single map-valued parameter
+// instead of requiring one proteus parameter per Consul key.
+type wildcardParam struct {
+	setName string
+	pattern string
+}
+
+// matchWildcardParam reports whether paramName matches a registered
+// WithWildcardParam pattern for setName, returning the pattern so the
+// caller can group every key matching the same pattern under one
+// wildcardKey.
+func (r *provider) matchWildcardParam(setName, paramName string) (pattern string, ok bool) {
+	for _, w := range r.wildcardParams {
+		if w.setName != setName {
+			continue
+		}
+
+		if matched, _ := path.Match(w.pattern, paramName); matched {
+			return w.pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// wildcardKey is the ret/modifyIndex key a matched wildcard parameter's
+// aggregated value is stored under: it reads like a regular
+// "<set>/<param>" key even though it's assembled from many Consul keys.
+func wildcardKey(setName, pattern string) string {
+	return setName + "/" + pattern
+}
+
+// collectWildcardValues encodes every wildcardKey's accumulated
+// paramName->value map as a JSON object and stores it in ret, so
+// listByPrefix's single pass over KV pairs can group matches as it
+// goes and only pay the JSON marshaling cost once per pattern.
+func collectWildcardValues(ret types.ParamValues, wildcardValues map[string]map[string]string) {
+	for key, values := range wildcardValues {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			continue
+		}
+
+		ret[key] = string(encoded)
+	}
+}
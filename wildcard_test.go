@@ -0,0 +1,92 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func TestListAggregatesWildcardMatchesIntoOneParameter(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/features/flag.foo", Value: []byte("1")},
+		{Key: "app/features/flag.bar", Value: []byte("2")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{})
+	p.wildcardParams = []wildcardParam{{setName: "features", pattern: "flag.*"}}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, ok := ret["features/flag.*"]
+	if !ok {
+		t.Fatalf("ret = %v, want a features/flag.* entry", ret)
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		t.Fatalf("features/flag.* value is not valid JSON: %v", err)
+	}
+
+	want := map[string]string{"flag.foo": "1", "flag.bar": "2"}
+	if len(decoded) != len(want) || decoded["flag.foo"] != want["flag.foo"] || decoded["flag.bar"] != want["flag.bar"] {
+		t.Fatalf("decoded = %v, want %v", decoded, want)
+	}
+}
+
+func TestListWildcardMatchBypassesParamNames(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/features/flag.foo", Value: []byte("1")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{})
+	p.wildcardParams = []wildcardParam{{setName: "features", pattern: "flag.*"}}
+
+	var ignored []string
+	p.onIgnoredKey = func(fullKey, reason string) {
+		ignored = append(ignored, fullKey+": "+reason)
+	}
+
+	if _, err := p.list(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ignored) != 0 {
+		t.Fatalf("ignored = %v, want no keys ignored for a wildcard match", ignored)
+	}
+}
+
+func TestListNonWildcardKeyStillNeedsParamNames(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/features/flag.foo", Value: []byte("1")},
+		{Key: "app/features/other", Value: []byte("x")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{})
+	p.wildcardParams = []wildcardParam{{setName: "features", pattern: "flag.*"}}
+
+	ret, err := p.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := ret["features/other"]; ok {
+		t.Fatalf("ret = %v, want features/other dropped as an unknown parameter", ret)
+	}
+}
+
+func TestMatchWildcardParamRequiresSetNameMatch(t *testing.T) {
+	p := &provider{wildcardParams: []wildcardParam{{setName: "features", pattern: "flag.*"}}}
+
+	if _, ok := p.matchWildcardParam("other", "flag.foo"); ok {
+		t.Fatal("matchWildcardParam matched a pattern registered for a different setName")
+	}
+
+	if _, ok := p.matchWildcardParam("features", "flag.foo"); !ok {
+		t.Fatal("matchWildcardParam did not match a registered pattern")
+	}
+}
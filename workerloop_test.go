@@ -0,0 +1,137 @@
+package consul
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+func runWorker(p *provider, ctx context.Context) *sync.WaitGroup {
+	var stopped sync.WaitGroup
+	stopped.Add(1)
+
+	go p.updateWorker(ctx, &stopped)
+
+	return &stopped
+}
+
+func waitOrTimeout(t *testing.T, stopped *sync.WaitGroup, timeout time.Duration) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		stopped.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("updateWorker did not stop in time")
+	}
+}
+
+func TestUpdateWorkerSuccessPathAppliesUpdate(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{
+		{Key: "app/db/host", Value: []byte("localhost")},
+	}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	updater := &recordingUpdater{}
+	p.updater = updater
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopped := runWorker(p, ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for updater.calls == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	waitOrTimeout(t, stopped, time.Second)
+
+	if updater.calls == 0 {
+		t.Fatal("Update was never called on the success path")
+	}
+
+	if updater.last["db/host"] != "localhost" {
+		t.Fatalf("delivered value = %+v, want db/host=localhost", updater.last)
+	}
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil after a clean cancellation", err)
+	}
+}
+
+func TestUpdateWorkerErrorThenRecover(t *testing.T) {
+	kv := &fakeKV{
+		pairs:     consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}},
+		failCount: 2,
+		failErr:   errListFailed,
+	}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	updater := &recordingUpdater{}
+	p.updater = updater
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopped := runWorker(p, ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for updater.calls == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	waitOrTimeout(t, stopped, time.Second)
+
+	if updater.calls == 0 {
+		t.Fatal("Update was never called after the worker recovered from errors")
+	}
+}
+
+func TestUpdateWorkerContextCancellationStopsLoop(t *testing.T) {
+	kv := &fakeKV{pairs: consulapi.KVPairs{{Key: "app/db/host", Value: []byte("localhost")}}}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.updater = &recordingUpdater{}
+	p.backoffBase = time.Millisecond
+	p.backoffMax = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stopped := runWorker(p, ctx)
+	waitOrTimeout(t, stopped, time.Second)
+}
+
+func TestUpdateWorkerIterationContextCanceledStopsWithoutRecordingErr(t *testing.T) {
+	kv := &fakeKV{listErr: context.Canceled}
+
+	p := newTestProvider(kv, map[string]bool{"db/host": true})
+	p.updater = &recordingUpdater{}
+
+	backoff := newBackoff(time.Millisecond, time.Millisecond)
+
+	if stop := p.updateWorkerIteration(context.Background(), backoff); !stop {
+		t.Fatal("updateWorkerIteration did not report stop for a context.Canceled list error")
+	}
+
+	// recordWorkerExit special-cases context.Canceled so Err() stays
+	// nil after Stop, matching a clean shutdown rather than a failure.
+	p.recordWorkerExit()
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil for a context.Canceled exit", err)
+	}
+}